@@ -2,9 +2,12 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
 
 	"SimpleAI/modWindowMemory"
@@ -15,24 +18,61 @@ import (
 // Version is set during build from wails.json
 var Version = "ersion dev"
 
+// Background position saving (see modWindowMemory/watcher.go)
+const (
+	positionSampleInterval = 2 * time.Second
+	positionSaveDelay      = time.Second // Window must be left alone this long before it is saved
+)
+
+// positionsLockTimeout bounds the wait for other instances when windows.json
+// is changed from the command line or by a layout
+const positionsLockTimeout = 5 * time.Second
+
 // App struct
 type App struct {
 	ctx            context.Context
 	startupService string
+	startupURL     string // Optional start page from --url, overrides the service home page
+	profile        string // WebView profile from --profile, "" for the default profile
+	incognito      bool   // --incognito: throwaway storage, nothing is saved
+	incognitoDir   string // Temporary storage of an incognito window
+	services       *ServiceRegistry
 	windowPosMgr   *modWindowMemory.WindowPositionManager
-	windowPosPath  string // Path to windows.json
+	windowPosPath  string                           // Path to windows.json
+	configDir      string                           // User config directory (parent of SimpleAI/)
+	settings       *Settings                        // User preferences from settings.json
+	control        *controlServer                   // Single-instance control socket, nil if unavailable
+	location       *locationTracker                 // Last-page tracking for service windows, nil for the launcher
+	keepSession    bool                             // Closed by "close all": stays in the session for restore
+	started        chan struct{}                    // Closed by startup; control requests wait for it
+	watcher        *modWindowMemory.PositionWatcher // Saves moves while running, nil for incognito windows
 }
 
 // NewApp creates a new App application struct
 func NewApp() *App {
 	configDir, _ := os.UserConfigDir()
 
+	// Built-in services plus user-defined ones from services.json
+	services := NewServiceRegistry()
+	for _, err := range services.LoadCustomServices(filepath.Join(configDir, "SimpleAI", "services.json")) {
+		println("[Services] Ignoring custom service:", err.Error())
+	}
+
 	return &App{
+		services:      services,
 		windowPosMgr:  modWindowMemory.NewWindowPositionManager(),
 		windowPosPath: filepath.Join(configDir, "SimpleAI", "windows.json"),
+		configDir:     configDir,
+		settings:      loadSettings(filepath.Join(configDir, "SimpleAI", "settings.json")),
+		started:       make(chan struct{}),
 	}
 }
 
+// settingsPath returns the path of settings.json
+func (a *App) settingsPath() string {
+	return filepath.Join(a.configDir, "SimpleAI", "settings.json")
+}
+
 // startup is called when the app starts. The context is saved
 // so we can call the runtime methods
 func (a *App) startup(ctx context.Context) {
@@ -42,28 +82,62 @@ func (a *App) startup(ctx context.Context) {
 		println("[DEBUG] Startup - Service:", a.startupService, "PID:", os.Getpid())
 	}
 
-	a.windowPosMgr.Load(a.windowPosPath)
+	// A damaged windows.json is repaired from its backup (see modWindowMemory/storage.go)
+	if err := a.windowPosMgr.Load(a.windowPosPath); err != nil {
+		println("[WindowPos] Could not load window positions:", err.Error())
+	}
 
 	// Get window title for position restore
-	windowTitle := "SimpleAI"
-	if a.startupService != "" {
-		// Find service label
-		serviceLabels := map[string]string{
-			"chatgpt":    "ChatGPT",
-			"claude":     "Claude (Sonnet)",
-			"copilot":    "Copilot",
-			"deepseek":   "Deepseek",
-			"gemini":     "Gemini",
-			"grok":       "Grok",
-			"meta":       "Meta AI",
-			"perplexity": "Perplexity",
-		}
-		if label, ok := serviceLabels[a.startupService]; ok {
-			windowTitle = "SimpleAI - " + label
+	windowTitle := a.GetWindowTitle()
+	wailsRuntime.WindowSetTitle(ctx, windowTitle)
+
+	// Windows without a saved position are placed next to the running ones
+	a.windowPosMgr.SetPlacement(a.settings.placement(), siblingWindows)
+	a.windowPosMgr.RestorePosition(ctx, a.positionID())
+
+	// Save moves and resizes while running, so a crash or logout doesn't lose them
+	if !a.incognito {
+		a.watcher = a.windowPosMgr.Watch(ctx, a.positionID(), a.windowPosPath, positionSampleInterval, positionSaveDelay)
+	}
+
+	// Remember the page shown in service windows (see location.go)
+	if service, ok := a.services.Lookup(a.startupService); ok {
+		a.startLocationTracking(ctx, service)
+	}
+
+	// Accept activation requests from later launches of the same service
+	info := InstanceInfo{
+		PID:       os.Getpid(),
+		Key:       a.instanceKey(),
+		Service:   a.startupService,
+		Profile:   a.profile,
+		Incognito: a.incognito,
+		Label:     windowTitle,
+		StartedAt: time.Now(),
+	}
+	if a.control != nil {
+		info.Endpoint = a.control.path
+	}
+	if err := registerInstance(info); err != nil {
+		println("[Instances] Could not register instance:", err.Error())
+	}
+	close(a.started) // Control requests of later launches are handled from now on
+
+	// Remember this window for session restore (see session.go)
+	if a.startupService != "" && !a.incognito {
+		window := SessionWindow{Service: a.startupService, Profile: a.profile, Opened: info.StartedAt}
+		if err := recordSessionWindow(a.configDir, a.instanceKey(), window); err != nil {
+			println("[Session] Could not record window:", err.Error())
 		}
 	}
-	wailsRuntime.WindowSetTitle(ctx, windowTitle)
-	a.windowPosMgr.RestorePosition(ctx, windowTitle)
+}
+
+// beforeClose runs when the window is about to close
+func (a *App) beforeClose(ctx context.Context) {
+	if a.watcher != nil {
+		a.watcher.Stop()
+	}
+	a.savePosition(ctx)
 }
 
 // shutdown is called when the app is about to quit
@@ -74,6 +148,118 @@ func (a *App) shutdown(ctx context.Context) {
 	}
 	// Note: Window position is already saved in OnBeforeClose hook (main.go)
 	// Don't save here as window may already be destroyed
+
+	// Session restore (see session.go); windows closed by "close all" stay in
+	// the session even if others are closed by hand later
+	if a.startupService != "" && !a.incognito {
+		endSessionWindow(a.configDir, a.instanceKey(), a.keepSession, time.Now())
+	}
+
+	if a.control != nil {
+		a.control.close()
+	}
+	if a.location != nil {
+		a.location.close()
+	}
+	unregisterInstance(os.Getpid())
+}
+
+// startLocationTracking follows the current page of a service window and
+// picks the saved page to reopen
+func (a *App) startLocationTracking(ctx context.Context, service Service) {
+	// Incognito windows neither restore nor record pages
+	remember := a.settings.remembersLastURL(service.ID) && !a.incognito
+	t := &locationTracker{
+		path:      pagePath(a.configDir, a.instanceKey()),
+		homeURL:   service.URL,
+		persist:   remember,
+		startedAt: time.Now(),
+		stop:      make(chan struct{}),
+	}
+	if remember && a.startupURL == "" {
+		t.restoredURL = loadSavedPage(t.path)
+	}
+	t.navigateHome = func() { a.navigate(service.URL) }
+	t.start(ctx)
+	a.location = t
+}
+
+// navigate loads a URL in this window
+func (a *App) navigate(target string) {
+	js, _ := json.Marshal(target)
+	wailsRuntime.WindowExecJS(a.ctx, "window.location.href = "+string(js)+";")
+}
+
+// instanceKey identifies this window for single-instance control,
+// e.g. "launcher", "chatgpt" or "chatgpt@work". Incognito windows are never
+// shared, so their key is unique per process.
+func (a *App) instanceKey() string {
+	if a.startupService == "" {
+		return cmdLauncher
+	}
+	if a.incognito {
+		return a.startupService + ".incognito-" + strconv.Itoa(os.Getpid())
+	}
+	return profileKey(a.startupService, a.profile)
+}
+
+// positionID is the key of this window's geometry in windows.json.
+// Incognito windows open where the regular window of the service was saved.
+func (a *App) positionID() string {
+	return profileTitle(a.services.WindowTitle(a.startupService), a.profile)
+}
+
+// savePosition stores the current window geometry, except for incognito windows
+func (a *App) savePosition(ctx context.Context) {
+	if a.incognito {
+		return
+	}
+	a.windowPosMgr.SavePosition(ctx, a.positionID(), a.windowPosPath)
+}
+
+// handleControl executes a request from another SimpleAI process.
+// Requests that arrive before startup wait until the window is up.
+func (a *App) handleControl(req controlRequest) controlResponse {
+	<-a.started
+
+	switch req.Command {
+	case controlActivate:
+		if req.URL != "" {
+			a.navigate(req.URL)
+		}
+		a.focusWindow()
+		return controlResponse{OK: true}
+	case controlQuit:
+		a.keepSession = req.KeepSession
+		// Quit after the response is sent; OnBeforeClose still saves the position
+		go wailsRuntime.Quit(a.ctx)
+		return controlResponse{OK: true}
+	case controlGeometry:
+		pos, ok := a.windowPosMgr.CurrentPosition(a.ctx)
+		if !ok {
+			return controlResponse{Error: "window geometry not available"}
+		}
+		return controlResponse{OK: true, Position: &pos, Screens: a.windowPosMgr.Screens(a.ctx)}
+	case controlPlace:
+		if req.Position == nil {
+			return controlResponse{Error: "place needs a position"}
+		}
+		a.windowPosMgr.ApplyPosition(a.ctx, a.GetWindowTitle(), *req.Position)
+		a.focusWindow()
+		return controlResponse{OK: true}
+	default:
+		return controlResponse{Error: "unknown command: " + req.Command}
+	}
+}
+
+// focusWindow brings this window to the front
+func (a *App) focusWindow() {
+	wailsRuntime.WindowUnminimise(a.ctx)
+	wailsRuntime.WindowShow(a.ctx)
+	// Toggling always-on-top raises the window on window managers that ignore show requests
+	wailsRuntime.WindowSetAlwaysOnTop(a.ctx, true)
+	wailsRuntime.WindowSetAlwaysOnTop(a.ctx, false)
+	activateOwnWindow()
 }
 
 // GetStartupService returns the service name to navigate to on startup
@@ -81,30 +267,80 @@ func (a *App) GetStartupService() string {
 	return a.startupService
 }
 
+// GetStartupURL returns the page the service window should load on startup,
+// or an empty string for the launcher
+func (a *App) GetStartupURL() string {
+	if a.startupURL != "" {
+		return a.startupURL
+	}
+	if a.location != nil {
+		if saved := a.location.startURL(); saved != "" {
+			return saved
+		}
+	}
+	if service, ok := a.services.Lookup(a.startupService); ok {
+		return service.URL
+	}
+	return ""
+}
+
+// GetRememberLastURL reports whether a service reopens its last visited page
+func (a *App) GetRememberLastURL(serviceID string) bool {
+	return a.settings.remembersLastURL(serviceID)
+}
+
+// SetRememberLastURL turns last-page memory on or off for a service.
+// Turning it off also forgets the saved page.
+func (a *App) SetRememberLastURL(serviceID string, enabled bool) error {
+	service, ok := a.services.Lookup(serviceID)
+	if !ok {
+		return fmt.Errorf("unknown service: %s", serviceID)
+	}
+
+	// Reload first so changes made by other instances are kept
+	a.settings = loadSettings(a.settingsPath())
+	a.settings.RememberLastURL[service.ID] = enabled
+	if !enabled {
+		for _, profile := range listProfiles() {
+			profile, _ = normalizeProfile(profile)
+			forgetSavedPage(pagePath(a.configDir, profileKey(service.ID, profile)))
+		}
+	}
+	return a.settings.save(a.settingsPath())
+}
+
 // GetVersion returns the application version
 func (a *App) GetVersion() string {
 	return Version
 }
 
-// GetWindowTitle returns the current window title based on startup service
+// GetWindowTitle returns the current window title based on startup service and profile
 func (a *App) GetWindowTitle() string {
-	windowTitle := "SimpleAI"
-	if a.startupService != "" {
-		serviceLabels := map[string]string{
-			"chatgpt":    "ChatGPT",
-			"claude":     "Claude (Sonnet)",
-			"copilot":    "Copilot",
-			"deepseek":   "Deepseek",
-			"gemini":     "Gemini",
-			"grok":       "Grok",
-			"meta":       "Meta AI",
-			"perplexity": "Perplexity",
-		}
-		if label, ok := serviceLabels[a.startupService]; ok {
-			windowTitle = "SimpleAI - " + label
-		}
+	if a.incognito {
+		return a.positionID() + " (Incognito)"
 	}
-	return windowTitle
+	return a.positionID()
+}
+
+// GetProfiles returns "default" followed by all named profiles
+func (a *App) GetProfiles() []string {
+	return listProfiles()
+}
+
+// CreateProfile creates a new named profile with its own WebView storage
+func (a *App) CreateProfile(name string) error {
+	return createProfile(name)
+}
+
+// GetServices returns all known AI services in launcher order
+func (a *App) GetServices() []Service {
+	return a.services.All()
+}
+
+// GetServiceProblems returns errors found in services.json, so the launcher can
+// report malformed custom services
+func (a *App) GetServiceProblems() []string {
+	return a.services.Problems()
 }
 
 // GoHome navigates back to the launcher page
@@ -115,93 +351,176 @@ func (a *App) GoHome() {
 // OpenNewInstance opens a new instance of the app with the specified service
 // or activates an existing window if one is already open
 func (a *App) OpenNewInstance(serviceName string) error {
+	return a.OpenProfileInstance(serviceName, "")
+}
+
+// OpenProfileInstance opens a service window using a named profile, or
+// activates the window if that service/profile combination is already open
+func (a *App) OpenProfileInstance(serviceName string, profileName string) error {
 	dbg := false // Set to true for debug output
 	if dbg {
-		println("[DEBUG] OpenNewInstance called for:", serviceName)
+		println("[DEBUG] OpenProfileInstance called for:", serviceName, profileName)
 	}
 
-	// Get window title for this service
-	serviceLabels := map[string]string{
-		"chatgpt":    "ChatGPT",
-		"claude":     "Claude (Sonnet)",
-		"copilot":    "Copilot",
-		"deepseek":   "Deepseek",
-		"gemini":     "Gemini",
-		"grok":       "Grok",
-		"meta":       "Meta AI",
-		"perplexity": "Perplexity",
+	service, ok := a.services.Lookup(serviceName)
+	if !ok {
+		return fmt.Errorf("unknown service: %s", serviceName)
+	}
+	profile, err := normalizeProfile(profileName)
+	if err != nil {
+		return err
 	}
 
-	windowTitle := "SimpleAI - " + serviceLabels[serviceName]
-	if dbg {
-		println("[DEBUG] Trying to find an existing window with title:", windowTitle)
-	}
-
-	// Try to find and activate existing window asynchronously
-	// This prevents blocking the UI while searching for windows
-	resultChan := make(chan struct {
-		found bool
-		err   error
-	}, 1)
-
-	go func() {
-		found, err := findAndActivateWindow(windowTitle)
-		resultChan <- struct {
-			found bool
-			err   error
-		}{found, err}
-	}()
-
-	// Wait for result with timeout
-	select {
-	case result := <-resultChan:
-		if result.err != nil {
-			if dbg {
-				println("[DEBUG] Error searching for window:", result.err)
-			}
-			// Continue to open new instance on error
-		}
-		if result.found {
-			if dbg {
-				println("[DEBUG] Found and activated existing window:", windowTitle)
-			}
-			return nil
-		}
-	case <-time.After(2 * time.Second):
-		// Timeout - proceed to open new instance
+	// Ask a running window of this service to come to the front (see control.go)
+	key := profileKey(service.ID, profile)
+	if err := activateControl(key, controlRequest{Command: controlActivate}); err == nil {
 		if dbg {
-			println("[DEBUG] Window search timed out, opening new instance")
+			println("[DEBUG] Activated running instance of", key)
 		}
+		return nil
 	}
 
-	// No existing window found, start new instance
+	// No running window, start new instance.
+	// The new process repeats the control check, so two quick clicks still
+	// result in a single window.
 	if dbg {
-		println("[DEBUG] No existing window found, starting new instance")
+		println("[DEBUG] No running instance found, starting new instance")
 	}
-	exePath, err := os.Executable()
+	args := []string{cmdOpen, service.ID}
+	if profile != "" {
+		args = append(args, "--profile", profile)
+	}
+	return spawnInstance(args...)
+}
+
+// OpenIncognitoInstance opens a service window with throwaway storage.
+// Incognito windows are always new windows.
+func (a *App) OpenIncognitoInstance(serviceName string) error {
+	service, ok := a.services.Lookup(serviceName)
+	if !ok {
+		return fmt.Errorf("unknown service: %s", serviceName)
+	}
+	return spawnInstance(cmdOpen, service.ID, "--incognito")
+}
+
+// GetPreviousSession returns the service windows that were open at last exit
+// and are not running now
+func (a *App) GetPreviousSession() []SessionWindow {
+	return previousSession(a.configDir, a.services)
+}
+
+// RestorePreviousSession reopens all windows of the previous session
+func (a *App) RestorePreviousSession() error {
+	_, err := restoreSession(a.configDir, a.services)
+	return err
+}
+
+// GetLayouts returns all saved workspace layouts
+func (a *App) GetLayouts() ([]Layout, error) {
+	return loadLayouts(layoutsPath(a.configDir))
+}
+
+// SaveLayout stores the open service windows as a layout
+func (a *App) SaveLayout(name string) (Layout, error) {
+	if err := validateLayoutName(name); err != nil {
+		return Layout{}, err
+	}
+	layout, err := captureLayout(name)
+	if err != nil {
+		return Layout{}, err
+	}
+	return layout, storeLayout(layoutsPath(a.configDir), layout)
+}
+
+// OpenLayout opens and arranges the windows of a layout
+func (a *App) OpenLayout(name string) error {
+	layout, err := getLayout(layoutsPath(a.configDir), name)
 	if err != nil {
 		return err
 	}
+	return a.openLayout(layout)
+}
+
+// DeleteLayout removes a layout
+func (a *App) DeleteLayout(name string) error {
+	return deleteLayout(layoutsPath(a.configDir), name)
+}
 
-	cmd := exec.Command(exePath, serviceName)
-	err = cmd.Start()
+// ArrangeWindows lays out all open service windows: tile, side-by-side, grid or stack
+func (a *App) ArrangeWindows(arrangement string) error {
+	parsed, err := modWindowMemory.ParseArrangement(arrangement)
 	if err != nil {
 		return err
 	}
+	if parsed.IsSnap() {
+		return fmt.Errorf("%s applies to one window, use SnapInstance", parsed)
+	}
+	return arrangeWindows(parsed)
+}
 
-	if dbg {
-		println("[DEBUG] Started new instance with PID:", cmd.Process.Pid)
+// SnapInstance moves the window of a running instance to the "left" or
+// "right" half of its screen
+func (a *App) SnapInstance(pid int, side string) error {
+	arrangement, err := modWindowMemory.ParseArrangement("snap-" + side)
+	if err != nil {
+		return fmt.Errorf("unknown side %q (use left or right)", side)
+	}
+	info, err := findInstance(pid)
+	if err != nil {
+		return err
+	}
+	return snapWindow(info.Key, arrangement)
+}
+
+// GetRunningInstances returns all running SimpleAI windows with their health status
+func (a *App) GetRunningInstances() ([]InstanceInfo, error) {
+	return listInstances()
+}
+
+// FocusInstance brings the window of a running instance to the front
+func (a *App) FocusInstance(pid int) error {
+	info, err := findInstance(pid)
+	if err != nil {
+		return err
+	}
+	allowForeground(info.PID) // See activateControl
+	return sendToInstance(info, controlRequest{Command: controlActivate})
+}
+
+// CloseInstance closes the window of a running instance
+func (a *App) CloseInstance(pid int) error {
+	info, err := findInstance(pid)
+	if err != nil {
+		return err
+	}
+	return sendToInstance(info, controlRequest{Command: controlQuit})
+}
+
+// CloseAllInstances closes all service windows, leaving launchers open.
+// The closed windows are reopened by session restore.
+func (a *App) CloseAllInstances() error {
+	instances, err := listInstances()
+	if err != nil {
+		return err
+	}
+
+	var failed []string
+	for _, info := range instances {
+		if info.Service == "" || info.PID == os.Getpid() {
+			continue
+		}
+		if err := sendToInstance(info, controlRequest{Command: controlQuit, KeepSession: true}); err != nil {
+			failed = append(failed, fmt.Sprintf("%s (PID %d): %v", info.Service, info.PID, err))
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("could not close %s", strings.Join(failed, "; "))
 	}
 	return nil
 }
 
 // SaveWindowPositionManual allows manual saving of window position from frontend
 func (a *App) SaveWindowPositionManual() error {
-	a.windowPosMgr.SavePosition(a.ctx, a.GetWindowTitle(), a.windowPosPath)
+	a.savePosition(a.ctx)
 	return nil
 }
-
-// findAndActivateWindow is implemented in platform-specific files:
-// - app_windows.go: Uses native Windows API for fast window search
-// - app_linux.go: Uses wmctrl or xdotool
-// - app_darwin.go: Uses osascript with AppleScript
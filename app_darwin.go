@@ -4,36 +4,35 @@
 package main
 
 import (
-	"fmt"
-	"os/exec"
-	"strings"
+	"os"
+	"path/filepath"
+	"syscall"
 )
 
-// findAndActivateWindow searches for a window with the given title and activates it
-// Returns true if window was found and activated, false otherwise
-func findAndActivateWindow(windowTitle string) (bool, error) {
-	// AppleScript to find and activate window by title
-	script := fmt.Sprintf(`
-		tell application "System Events"
-			set foundWindow to false
-			repeat with proc in (every process whose visible is true)
-				tell proc
-					if exists (windows whose name is "%s") then
-						set frontmost to true
-						set foundWindow to true
-						exit repeat
-					end if
-				end tell
-			end repeat
-			return foundWindow
-		end tell
-	`, windowTitle)
-
-	cmd := exec.Command("osascript", "-e", script)
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return false, err
-	}
-
-	return strings.Contains(string(output), "true"), nil
+// platformRuntimeDir returns the control socket directory if $XDG_RUNTIME_DIR
+// is not set. $TMPDIR is already private to the user on macOS.
+func platformRuntimeDir() string {
+	return filepath.Join(os.TempDir(), "SimpleAI")
 }
+
+// attachParentConsole is only needed on Windows; macOS GUI binaries keep the
+// terminal's stdout/stderr
+func attachParentConsole() {}
+
+// processAlive reports whether a process with the given PID exists
+func processAlive(pid int) bool {
+	err := syscall.Kill(pid, 0)
+	return err == nil || err == syscall.EPERM
+}
+
+// applyWebviewDataPath is not supported on macOS: WKWebView always uses the
+// app's default data store, so profiles share cookies and incognito is unavailable
+func applyWebviewDataPath(path string) bool {
+	return false
+}
+
+// activateOwnWindow is only needed on X11; the Wails runtime already brings the window to the front on macOS
+func activateOwnWindow() {}
+
+// allowForeground is only needed on Windows, see app_windows.go
+func allowForeground(pid int) {}
@@ -4,26 +4,58 @@
 package main
 
 import (
-	"os/exec"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"syscall"
+
+	"SimpleAI/modWindowMemory"
 )
 
-// findAndActivateWindow searches for a window with the given title and activates it
-// Returns true if window was found and activated, false otherwise
-func findAndActivateWindow(windowTitle string) (bool, error) {
-	// Try wmctrl first
-	cmd := exec.Command("wmctrl", "-a", windowTitle)
-	err := cmd.Run()
-	if err == nil {
-		return true, nil
-	}
+// platformRuntimeDir returns the control socket directory if $XDG_RUNTIME_DIR
+// is not set. The user ID keeps it separate per user in the shared temp dir.
+func platformRuntimeDir() string {
+	return filepath.Join(os.TempDir(), "SimpleAI-"+strconv.Itoa(os.Getuid()))
+}
 
-	// Fallback to xdotool
-	cmd = exec.Command("xdotool", "search", "--name", windowTitle, "windowactivate")
-	err = cmd.Run()
-	if err == nil {
-		return true, nil
+// attachParentConsole is only needed on Windows; Linux GUI binaries keep the
+// terminal's stdout/stderr
+func attachParentConsole() {}
+
+// processAlive reports whether a process with the given PID exists
+func processAlive(pid int) bool {
+	err := syscall.Kill(pid, 0)
+	return err == nil || err == syscall.EPERM
+}
+
+// applyWebviewDataPath points WebKitGTK's storage at path. Wails uses the default
+// WebKit context, which keeps cookies and cache under $XDG_DATA_HOME and
+// $XDG_CACHE_HOME, so redirecting those for this process isolates the profile.
+// Reports whether the storage is isolated.
+//
+// The environment is process-wide: cacheBaseDir must be resolved before, and
+// child processes get the environment without the override (childEnv).
+func applyWebviewDataPath(path string) bool {
+	cacheBaseDir()
+	if childEnv == nil {
+		childEnv = os.Environ()
 	}
+	os.Setenv("XDG_DATA_HOME", filepath.Join(path, "data"))
+	os.Setenv("XDG_CACHE_HOME", filepath.Join(path, "cache"))
+	return true
+}
 
-	// Neither tool worked or window not found
-	return false, nil
+// activateOwnWindow asks the window manager to activate this process's window
+// (_NET_ACTIVE_WINDOW on X11, focus command on sway/Hyprland). Some window
+// managers only raise a window for requests like this one (focus stealing
+// prevention). A missing backend was already reported by modWindowMemory.
+func activateOwnWindow() {
+	err := modWindowMemory.ActivateProcessWindow(os.Getpid())
+	if err != nil && !errors.Is(err, modWindowMemory.ErrNoBackend) {
+		println("[Instances] Could not activate window:", err.Error())
+	}
 }
+
+// allowForeground is only needed on Windows, see app_windows.go
+func allowForeground(pid int) {}
@@ -4,87 +4,84 @@
 package main
 
 import (
+	"os"
+	"path/filepath"
 	"syscall"
-	"unsafe"
 )
 
 var (
-	user32                   = syscall.NewLazyDLL("user32.dll")
-	procEnumWindows          = user32.NewProc("EnumWindows")
-	procGetWindowTextW       = user32.NewProc("GetWindowTextW")
-	procSetForegroundWindow  = user32.NewProc("SetForegroundWindow")
-	procShowWindow           = user32.NewProc("ShowWindow")
-	procIsIconic             = user32.NewProc("IsIconic")
-	procGetWindowTextLengthW = user32.NewProc("GetWindowTextLengthW")
-)
-
-// findWindowByTitle searches for a window with the exact title
-func findWindowByTitle(title string) (uintptr, error) {
-	var foundHwnd uintptr
-	targetTitle := title
-
-	// Callback function for EnumWindows
-	callback := syscall.NewCallback(func(hwnd uintptr, lparam uintptr) uintptr {
-		// Get window title length
-		length, _, _ := procGetWindowTextLengthW.Call(hwnd)
-		if length == 0 {
-			return 1 // Continue enumeration
-		}
-
-		// Get window title
-		buf := make([]uint16, length+1)
-		procGetWindowTextW.Call(hwnd, uintptr(unsafe.Pointer(&buf[0])), uintptr(length+1))
-		windowTitle := syscall.UTF16ToString(buf)
+	kernel32          = syscall.NewLazyDLL("kernel32.dll")
+	procAttachConsole = kernel32.NewProc("AttachConsole")
 
-		// Check if title matches
-		if windowTitle == targetTitle {
-			foundHwnd = hwnd
-			return 0 // Stop enumeration
-		}
-
-		return 1 // Continue enumeration
-	})
+	user32                       = syscall.NewLazyDLL("user32.dll")
+	procAllowSetForegroundWindow = user32.NewProc("AllowSetForegroundWindow")
+)
 
-	// Enumerate all top-level windows
-	procEnumWindows.Call(callback, 0)
+const (
+	processQueryLimitedInformation = 0x1000
+	stillActive                    = 259 // STILL_ACTIVE exit code
+)
 
-	return foundHwnd, nil
-}
+// ATTACH_PARENT_PROCESS attaches to the console of the process that started us
+const attachParentProcess = ^uintptr(0)
 
-// setForegroundWindow brings the specified window to the foreground
-func setForegroundWindow(hwnd uintptr) bool {
-	ret, _, _ := procSetForegroundWindow.Call(hwnd)
-	return ret != 0
+// platformRuntimeDir returns the control socket directory if $XDG_RUNTIME_DIR
+// is not set: %LOCALAPPDATA%\SimpleAI\run (AF_UNIX needs Windows 10 1803+)
+func platformRuntimeDir() string {
+	localAppData, err := os.UserCacheDir()
+	if err != nil {
+		localAppData = os.TempDir()
+	}
+	return filepath.Join(localAppData, "SimpleAI", "run")
 }
 
-// showWindow shows the window in the specified state
-func showWindow(hwnd uintptr, nCmdShow int) bool {
-	ret, _, _ := procShowWindow.Call(hwnd, uintptr(nCmdShow))
-	return ret != 0
-}
+// attachParentConsole connects stdout/stderr to the terminal SimpleAI was
+// started from. GUI builds (-H windowsgui) have no console of their own, so
+// command-line output would otherwise be lost.
+func attachParentConsole() {
+	ret, _, _ := procAttachConsole.Call(attachParentProcess)
+	if ret == 0 {
+		return // Started from Explorer or a shortcut - no console to attach to
+	}
 
-// isIconic checks if the window is minimized
-func isIconic(hwnd uintptr) bool {
-	ret, _, _ := procIsIconic.Call(hwnd)
-	return ret != 0
+	conout, err := os.OpenFile("CONOUT$", os.O_WRONLY, 0)
+	if err != nil {
+		return
+	}
+	os.Stdout = conout
+	os.Stderr = conout
 }
 
-// findAndActivateWindow searches for a window with the given title and activates it
-// Returns true if window was found and activated, false otherwise
-func findAndActivateWindow(windowTitle string) (bool, error) {
-	// Use native Go syscall for direct Windows API access - much faster than PowerShell
-	hwnd, err := findWindowByTitle(windowTitle)
-	if err != nil || hwnd == 0 {
-		return false, nil
+// processAlive reports whether a process with the given PID is still running
+func processAlive(pid int) bool {
+	handle, err := syscall.OpenProcess(processQueryLimitedInformation, false, uint32(pid))
+	if err != nil {
+		return false
 	}
+	defer syscall.CloseHandle(handle)
 
-	// Check if window is minimized (iconic)
-	if isIconic(hwnd) {
-		// SW_RESTORE = 9
-		showWindow(hwnd, 9)
+	var exitCode uint32
+	if err := syscall.GetExitCodeProcess(handle, &exitCode); err != nil {
+		return false
 	}
+	return exitCode == stillActive
+}
+
+// applyWebviewDataPath has nothing to do on Windows: WebView2 takes the storage
+// directory from options.Windows.WebviewUserDataPath
+func applyWebviewDataPath(path string) bool {
+	return true
+}
 
-	// Bring window to foreground
-	setForegroundWindow(hwnd)
-	return true, nil
+// activateOwnWindow has nothing to do on Windows: the Wails runtime brings the
+// window to the front, because the process that sent the request allowed it
+// with allowForeground
+func activateOwnWindow() {}
+
+// allowForeground lets another process bring its window to the front. The
+// foreground lock only allows this for the process the user is working with,
+// so a launching process hands its right to the instance it activates;
+// otherwise the taskbar entry would only flash.
+func allowForeground(pid int) {
+	procAllowSetForegroundWindow.Call(uintptr(pid))
 }
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"SimpleAI/modWindowMemory"
+)
+
+// Window arrangement
+//
+// "SimpleAI arrange grid" and the launcher's arrange buttons lay out all open
+// service windows at once; snapping moves one window to the left or right
+// half of its screen. The geometry comes from modWindowMemory.Arrange. Every
+// window is asked for its geometry and the screens through the control socket
+// and then sent a place command, so each instance moves itself through its
+// platform backend like when a layout is opened.
+
+// placeTimeout bounds each place command, so an instance that hangs doesn't
+// hold up the other windows
+const placeTimeout = time.Second
+
+// openWindow is a running service window and its current bounds
+type openWindow struct {
+	info   InstanceInfo
+	bounds modWindowMemory.Rect
+}
+
+// openServiceWindows returns the running service windows, oldest first, and
+// the screens as seen by them
+func openServiceWindows() ([]openWindow, []modWindowMemory.Rect, error) {
+	instances, err := listInstances()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var windows []openWindow
+	var screens []modWindowMemory.Rect
+	for _, info := range instances {
+		if info.Service == "" || info.Status != instanceRunning {
+			continue
+		}
+		resp, err := queryInstance(info, controlRequest{Command: controlGeometry})
+		if err != nil {
+			println("[Arrange] Skipping", info.Key, "-", err.Error())
+			continue
+		}
+		window := openWindow{info: info}
+		if pos := resp.Position; pos != nil && !pos.Minimised {
+			window.bounds = modWindowMemory.Rect{X: pos.X, Y: pos.Y, Width: pos.Width, Height: pos.Height}
+		}
+		windows = append(windows, window)
+		if len(screens) == 0 {
+			screens = resp.Screens
+		}
+	}
+	return windows, screens, nil
+}
+
+// arrangeWindows lays out all open service windows
+func arrangeWindows(arrangement modWindowMemory.Arrangement) error {
+	windows, screens, err := openServiceWindows()
+	if err != nil {
+		return err
+	}
+	if len(windows) == 0 {
+		return errors.New("no open service windows to arrange")
+	}
+	return placeWindows(arrangement, windows, screens)
+}
+
+// snapWindow moves the window of an instance key to the left or right half of its screen
+func snapWindow(key string, arrangement modWindowMemory.Arrangement) error {
+	windows, screens, err := openServiceWindows()
+	if err != nil {
+		return err
+	}
+	for _, window := range windows {
+		if window.info.Key == key {
+			return placeWindows(arrangement, []openWindow{window}, screens)
+		}
+	}
+	return fmt.Errorf("%s is not open or doesn't answer", key)
+}
+
+// placeWindows computes the arrangement and sends each window its bounds.
+// Windows are placed in order, so the last one ends up on top.
+func placeWindows(arrangement modWindowMemory.Arrangement, windows []openWindow, screens []modWindowMemory.Rect) error {
+	positions, err := arrangedPositions(arrangement, windows, screens)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for i, window := range windows {
+		_, err := queryInstanceWithin(window.info, controlRequest{Command: controlPlace, Position: &positions[i]}, placeTimeout)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", window.info.Key, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// arrangedPositions returns the new position of each window, in the order of
+// windows, without moving any
+func arrangedPositions(arrangement modWindowMemory.Arrangement, windows []openWindow, screens []modWindowMemory.Rect) ([]modWindowMemory.WindowPosition, error) {
+	current := make([]modWindowMemory.Rect, len(windows))
+	for i, window := range windows {
+		current[i] = window.bounds
+	}
+	bounds := modWindowMemory.Arrange(arrangement, current, screens)
+	if bounds == nil {
+		return nil, errors.New("screen size not available")
+	}
+
+	positions := make([]modWindowMemory.WindowPosition, len(bounds))
+	for i, b := range bounds {
+		positions[i] = modWindowMemory.WindowPosition{X: b.X, Y: b.Y, Width: b.Width, Height: b.Height}
+	}
+	return positions, nil
+}
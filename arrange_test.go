@@ -0,0 +1,175 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"SimpleAI/modWindowMemory"
+)
+
+// testWindows returns count windows with bounds inside a screen
+func testWindows(count int, on modWindowMemory.Rect) []openWindow {
+	windows := make([]openWindow, count)
+	for i := range windows {
+		windows[i] = openWindow{
+			info:   InstanceInfo{Key: fmt.Sprintf("window%d", i)},
+			bounds: modWindowMemory.Rect{X: on.X + 10*i, Y: on.Y + 10*i, Width: 800, Height: 600},
+		}
+	}
+	return windows
+}
+
+// inside reports whether a rect lies within one of the screens
+func inside(r modWindowMemory.Rect, screens []modWindowMemory.Rect) bool {
+	for _, s := range screens {
+		if r.X >= s.X && r.Y >= s.Y && r.X+r.Width <= s.X+s.Width && r.Y+r.Height <= s.Y+s.Height {
+			return true
+		}
+	}
+	return false
+}
+
+func overlap(a, b modWindowMemory.Rect) bool {
+	return a.X < b.X+b.Width && b.X < a.X+a.Width && a.Y < b.Y+b.Height && b.Y < a.Y+a.Height
+}
+
+func TestArrangedPositions(t *testing.T) {
+	primary := modWindowMemory.Rect{X: 0, Y: 0, Width: 1920, Height: 1080}
+	second := modWindowMemory.Rect{X: 1920, Y: 0, Width: 2560, Height: 1440}
+	layouts := []struct {
+		name    string
+		screens []modWindowMemory.Rect
+	}{
+		{"one screen", []modWindowMemory.Rect{primary}},
+		{"two screens", []modWindowMemory.Rect{primary, second}},
+	}
+	tiled := map[modWindowMemory.Arrangement]bool{
+		modWindowMemory.ArrangeTile:       true,
+		modWindowMemory.ArrangeSideBySide: true,
+		modWindowMemory.ArrangeGrid:       true,
+	}
+
+	for _, layout := range layouts {
+		for _, arrangement := range modWindowMemory.Arrangements {
+			for count := 1; count <= 6; count++ {
+				name := fmt.Sprintf("%s/%s/%d", layout.name, arrangement, count)
+				t.Run(name, func(t *testing.T) {
+					positions, err := arrangedPositions(arrangement, testWindows(count, primary), layout.screens)
+					if err != nil {
+						t.Fatal(err)
+					}
+					if len(positions) != count {
+						t.Fatalf("%d positions, want %d", len(positions), count)
+					}
+					cells := make([]modWindowMemory.Rect, count)
+					for i, pos := range positions {
+						cells[i] = modWindowMemory.Rect{X: pos.X, Y: pos.Y, Width: pos.Width, Height: pos.Height}
+						if cells[i].Width <= 0 || cells[i].Height <= 0 || !inside(cells[i], layout.screens) {
+							t.Errorf("window %d at %+v, not on a screen", i, cells[i])
+						}
+					}
+					if !tiled[arrangement] {
+						return
+					}
+					for i := range cells {
+						for j := i + 1; j < count; j++ {
+							if overlap(cells[i], cells[j]) {
+								t.Errorf("windows %d %+v and %d %+v overlap", i, cells[i], j, cells[j])
+							}
+						}
+					}
+				})
+			}
+		}
+	}
+}
+
+// TestArrangedPositionsOdd checks the exact cells of odd window counts, where
+// the last row or the second screen has fewer windows
+func TestArrangedPositionsOdd(t *testing.T) {
+	primary := modWindowMemory.Rect{X: 0, Y: 0, Width: 1920, Height: 1080}
+	second := modWindowMemory.Rect{X: 1920, Y: 0, Width: 2560, Height: 1440}
+	tests := []struct {
+		name        string
+		arrangement modWindowMemory.Arrangement
+		windows     []openWindow
+		screens     []modWindowMemory.Rect
+		want        []modWindowMemory.WindowPosition
+	}{
+		{
+			name:        "grid of 3",
+			arrangement: modWindowMemory.ArrangeGrid,
+			windows:     testWindows(3, primary),
+			screens:     []modWindowMemory.Rect{primary},
+			want: []modWindowMemory.WindowPosition{
+				{X: 0, Y: 0, Width: 960, Height: 540},
+				{X: 960, Y: 0, Width: 960, Height: 540},
+				{X: 0, Y: 540, Width: 1920, Height: 540},
+			},
+		},
+		{
+			name:        "grid of 5",
+			arrangement: modWindowMemory.ArrangeGrid,
+			windows:     testWindows(5, primary),
+			screens:     []modWindowMemory.Rect{primary},
+			want: []modWindowMemory.WindowPosition{
+				{X: 0, Y: 0, Width: 640, Height: 540},
+				{X: 640, Y: 0, Width: 640, Height: 540},
+				{X: 1280, Y: 0, Width: 640, Height: 540},
+				{X: 0, Y: 540, Width: 960, Height: 540},
+				{X: 960, Y: 540, Width: 960, Height: 540},
+			},
+		},
+		{
+			name:        "side by side of 3",
+			arrangement: modWindowMemory.ArrangeSideBySide,
+			windows:     testWindows(3, primary),
+			screens:     []modWindowMemory.Rect{primary},
+			want: []modWindowMemory.WindowPosition{
+				{X: 0, Y: 0, Width: 640, Height: 1080},
+				{X: 640, Y: 0, Width: 640, Height: 1080},
+				{X: 1280, Y: 0, Width: 640, Height: 1080},
+			},
+		},
+		{
+			name:        "tile of 3 on two screens",
+			arrangement: modWindowMemory.ArrangeTile,
+			windows:     testWindows(3, primary),
+			screens:     []modWindowMemory.Rect{primary, second},
+			want: []modWindowMemory.WindowPosition{
+				{X: 0, Y: 0, Width: 960, Height: 1080},
+				{X: 960, Y: 0, Width: 960, Height: 1080},
+				{X: 1920, Y: 0, Width: 2560, Height: 1440},
+			},
+		},
+		{
+			name:        "grid of 3 on the screen the windows are on",
+			arrangement: modWindowMemory.ArrangeGrid,
+			windows:     testWindows(3, second),
+			screens:     []modWindowMemory.Rect{primary, second},
+			want: []modWindowMemory.WindowPosition{
+				{X: 1920, Y: 0, Width: 1280, Height: 720},
+				{X: 3200, Y: 0, Width: 1280, Height: 720},
+				{X: 1920, Y: 720, Width: 2560, Height: 720},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := arrangedPositions(tt.arrangement, tt.windows, tt.screens)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("arrangedPositions() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestArrangedPositionsNoScreens(t *testing.T) {
+	if _, err := arrangedPositions(modWindowMemory.ArrangeTile, testWindows(2, modWindowMemory.Rect{}), nil); err == nil {
+		t.Error("arrangedPositions() without screens succeeded")
+	}
+}
@@ -0,0 +1,501 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"text/tabwriter"
+
+	"SimpleAI/modWindowMemory"
+)
+
+// Exit codes of the command-line interface
+const (
+	exitOK             = 0 // Success
+	exitFailure        = 1 // Command failed (I/O error, window not reachable, ...)
+	exitUsage          = 2 // Invalid command, flag or argument
+	exitUnknownService = 3 // Service ID or alias is not known
+)
+
+// Command names
+const (
+	cmdLauncher       = "launcher" // No arguments: show the launcher window
+	cmdOpen           = "open"
+	cmdList           = "list"
+	cmdVersion        = "version"
+	cmdResetPositions = "reset-positions"
+	cmdProfiles       = "profiles"
+	cmdLayout         = "layout"
+	cmdArrange        = "arrange"
+	cmdHelp           = "help"
+)
+
+// reservedNames can't be used as service IDs or aliases: "SimpleAI <name>"
+// runs the command, and "launcher" is the launcher's instance key
+var reservedNames = []string{cmdLauncher, cmdOpen, cmdList, cmdVersion, cmdResetPositions, cmdProfiles, cmdLayout, cmdArrange, cmdHelp}
+
+// Subcommands of "layout"; any other argument is the name of a layout to open
+const (
+	layoutOpen   = "open"
+	layoutSave   = "save"
+	layoutList   = "list"
+	layoutDelete = "delete"
+)
+
+const usageText = `Usage: SimpleAI [command] [options]
+
+Commands:
+  (none)                         Open the launcher
+      --restore-session            Also reopen the service windows of the previous session
+  open <service> [options]       Open a service window
+      --url <url>                  Start at this URL instead of the service home page
+      --new                        Always open a new window, don't activate an existing one
+      --profile <name>             Use a separate login/cookie profile (e.g. work, personal)
+      --incognito                  Throwaway session: temporary storage, nothing is saved
+  <service>                      Shortcut for "open <service>"
+  list [--json]                  Print all known services
+  reset-positions [service] [--profile <name>]
+                                 Forget saved window positions (all or one service)
+  profiles                       Print all profiles
+  layout <name>                  Open and arrange the windows of a saved layout
+  layout save <name>             Save the open service windows as a layout
+  layout list                    Print all layouts
+  layout delete <name>           Delete a layout
+  arrange <tile|side-by-side|grid|stack>
+                                 Arrange all open service windows
+  arrange <snap-left|snap-right> <service> [--profile <name>]
+                                 Snap a service window to half of its screen
+  version                        Print the version
+  help                           Show this help
+
+Exit codes: 0 success, 1 failure, 2 usage error, 3 unknown service
+`
+
+// cliCommand is a parsed command line
+type cliCommand struct {
+	name      string
+	service   string // Canonical service ID (open, reset-positions, arrange snap-*)
+	profile   string // --profile, "" for the default profile
+	url       string // --url
+	newWindow bool   // --new
+	incognito bool   // --incognito
+	json      bool   // list --json
+	action    string // layout subcommand or arrangement
+	layout    string // Layout name
+
+	restoreSession bool // --restore-session
+}
+
+// usageError reports an invalid command line
+type usageError struct {
+	msg string
+}
+
+func (e *usageError) Error() string { return e.msg }
+
+// unknownServiceError reports a service name that is not in the registry
+type unknownServiceError struct {
+	name string
+}
+
+func (e *unknownServiceError) Error() string {
+	return fmt.Sprintf("unknown service %q (run \"SimpleAI list\" to see all services)", e.name)
+}
+
+// exitCodeFor maps a command-line error to the process exit code
+func exitCodeFor(err error) int {
+	var usageErr *usageError
+	var serviceErr *unknownServiceError
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.As(err, &serviceErr):
+		return exitUnknownService
+	case errors.As(err, &usageErr):
+		return exitUsage
+	default:
+		return exitFailure
+	}
+}
+
+// parseCommandLine turns os.Args[1:] into a command.
+// A bare service ID or alias is accepted for compatibility with older versions.
+func parseCommandLine(args []string, services *ServiceRegistry) (*cliCommand, error) {
+	args = filterPlatformArgs(args)
+	if len(args) == 0 {
+		return &cliCommand{name: cmdLauncher}, nil
+	}
+
+	name := strings.ToLower(args[0])
+	rest := args[1:]
+	switch name {
+	case "--restore-session":
+		if len(rest) != 0 {
+			return nil, &usageError{"--restore-session takes no arguments"}
+		}
+		return &cliCommand{name: cmdLauncher, restoreSession: true}, nil
+	case cmdHelp, "-h", "--help", "-help":
+		return &cliCommand{name: cmdHelp}, nil
+	case cmdVersion, "-v", "--version", "-version":
+		return &cliCommand{name: cmdVersion}, nil
+	case cmdList:
+		return parseList(rest)
+	case cmdOpen:
+		return parseOpen(rest, services)
+	case cmdResetPositions:
+		return parseResetPositions(rest, services)
+	case cmdProfiles:
+		if len(rest) != 0 {
+			return nil, &usageError{"profiles takes no arguments"}
+		}
+		return &cliCommand{name: cmdProfiles}, nil
+	case cmdLayout:
+		return parseLayout(rest)
+	case cmdArrange:
+		return parseArrange(rest, services)
+	}
+
+	if strings.HasPrefix(name, "-") {
+		return nil, &usageError{fmt.Sprintf("unknown option %q", args[0])}
+	}
+	// Bare service name, e.g. "SimpleAI chatgpt --new"
+	return parseOpen(args, services)
+}
+
+// filterPlatformArgs drops arguments added by the operating system,
+// e.g. the process serial number macOS passes when started from Finder
+func filterPlatformArgs(args []string) []string {
+	filtered := make([]string, 0, len(args))
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "-psn_") {
+			continue
+		}
+		filtered = append(filtered, arg)
+	}
+	return filtered
+}
+
+// parseFlags parses flags that may appear before, between or after positional
+// arguments and returns the positional arguments
+func parseFlags(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, &usageError{err.Error()}
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// newFlagSet creates a flag set that reports errors instead of exiting
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	return fs
+}
+
+func parseOpen(args []string, services *ServiceRegistry) (*cliCommand, error) {
+	cmd := &cliCommand{name: cmdOpen}
+	fs := newFlagSet(cmdOpen)
+	fs.StringVar(&cmd.url, "url", "", "start URL")
+	fs.BoolVar(&cmd.newWindow, "new", false, "always open a new window")
+	fs.StringVar(&cmd.profile, "profile", "", "profile name")
+	fs.BoolVar(&cmd.incognito, "incognito", false, "throwaway session")
+
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return nil, err
+	}
+	if cmd.profile, err = normalizeProfile(cmd.profile); err != nil {
+		return nil, &usageError{err.Error()}
+	}
+	if cmd.incognito && cmd.profile != "" {
+		return nil, &usageError{"--incognito and --profile cannot be combined"}
+	}
+	if len(positional) != 1 {
+		return nil, &usageError{"open expects exactly one service"}
+	}
+
+	service, ok := services.Lookup(positional[0])
+	if !ok {
+		return nil, &unknownServiceError{positional[0]}
+	}
+	cmd.service = service.ID
+
+	if cmd.url != "" {
+		u, err := url.Parse(cmd.url)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, &usageError{fmt.Sprintf("--url must be an absolute http(s) URL, got %q", cmd.url)}
+		}
+	}
+	return cmd, nil
+}
+
+func parseList(args []string) (*cliCommand, error) {
+	cmd := &cliCommand{name: cmdList}
+	fs := newFlagSet(cmdList)
+	fs.BoolVar(&cmd.json, "json", false, "print JSON")
+
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return nil, err
+	}
+	if len(positional) != 0 {
+		return nil, &usageError{"list takes no arguments"}
+	}
+	return cmd, nil
+}
+
+func parseResetPositions(args []string, services *ServiceRegistry) (*cliCommand, error) {
+	cmd := &cliCommand{name: cmdResetPositions}
+	fs := newFlagSet(cmdResetPositions)
+	fs.StringVar(&cmd.profile, "profile", "", "profile name")
+
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return nil, err
+	}
+	if cmd.profile, err = normalizeProfile(cmd.profile); err != nil {
+		return nil, &usageError{err.Error()}
+	}
+	switch len(positional) {
+	case 0:
+		if cmd.profile != "" {
+			return nil, &usageError{"reset-positions --profile needs a service"}
+		}
+		return cmd, nil
+	case 1:
+		service, ok := services.Lookup(positional[0])
+		if !ok {
+			return nil, &unknownServiceError{positional[0]}
+		}
+		cmd.service = service.ID
+		return cmd, nil
+	default:
+		return nil, &usageError{"reset-positions takes at most one service"}
+	}
+}
+
+func parseLayout(args []string) (*cliCommand, error) {
+	if len(args) == 0 {
+		return nil, &usageError{"layout needs a layout name or save, list, delete"}
+	}
+
+	cmd := &cliCommand{name: cmdLayout, action: layoutOpen, layout: strings.ToLower(args[0])}
+	rest := args[1:]
+	switch cmd.layout {
+	case layoutList:
+		if len(rest) != 0 {
+			return nil, &usageError{"layout list takes no arguments"}
+		}
+		cmd.action, cmd.layout = layoutList, ""
+		return cmd, nil
+	case layoutSave, layoutDelete:
+		if len(rest) != 1 {
+			return nil, &usageError{fmt.Sprintf("layout %s needs exactly one layout name", cmd.layout)}
+		}
+		cmd.action, cmd.layout = cmd.layout, strings.ToLower(rest[0])
+		if cmd.action == layoutSave {
+			if err := validateLayoutName(cmd.layout); err != nil {
+				return nil, &usageError{err.Error()}
+			}
+		}
+		return cmd, nil
+	}
+
+	if len(rest) != 0 {
+		return nil, &usageError{fmt.Sprintf("unexpected argument %q", rest[0])}
+	}
+	return cmd, nil
+}
+
+func parseArrange(args []string, services *ServiceRegistry) (*cliCommand, error) {
+	cmd := &cliCommand{name: cmdArrange}
+	fs := newFlagSet(cmdArrange)
+	fs.StringVar(&cmd.profile, "profile", "", "profile name")
+
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return nil, err
+	}
+	if cmd.profile, err = normalizeProfile(cmd.profile); err != nil {
+		return nil, &usageError{err.Error()}
+	}
+	if len(positional) == 0 {
+		return nil, &usageError{"arrange needs an arrangement: tile, side-by-side, grid, stack, snap-left or snap-right"}
+	}
+	arrangement, err := modWindowMemory.ParseArrangement(strings.ToLower(positional[0]))
+	if err != nil {
+		return nil, &usageError{err.Error()}
+	}
+	cmd.action = string(arrangement)
+
+	if !arrangement.IsSnap() {
+		if len(positional) != 1 || cmd.profile != "" {
+			return nil, &usageError{fmt.Sprintf("arrange %s applies to all windows and takes no service", arrangement)}
+		}
+		return cmd, nil
+	}
+	if len(positional) != 2 {
+		return nil, &usageError{fmt.Sprintf("arrange %s needs exactly one service", arrangement)}
+	}
+	service, ok := services.Lookup(positional[1])
+	if !ok {
+		return nil, &unknownServiceError{positional[1]}
+	}
+	cmd.service = service.ID
+	return cmd, nil
+}
+
+// opensWindow reports whether the command needs the GUI
+func (c *cliCommand) opensWindow() bool {
+	return c.name == cmdLauncher || c.name == cmdOpen
+}
+
+// run executes a command that doesn't need the GUI and returns the exit code
+func (c *cliCommand) run(app *App, stdout, stderr io.Writer) int {
+	switch c.name {
+	case cmdHelp:
+		fmt.Fprint(stdout, usageText)
+	case cmdVersion:
+		fmt.Fprintln(stdout, "SimpleAI", Version)
+	case cmdList:
+		return c.runList(app, stdout, stderr)
+	case cmdResetPositions:
+		return c.runResetPositions(app, stdout, stderr)
+	case cmdProfiles:
+		for _, profile := range listProfiles() {
+			fmt.Fprintln(stdout, profile)
+		}
+	case cmdLayout:
+		return c.runLayout(app, stdout, stderr)
+	case cmdArrange:
+		return c.runArrange(stderr)
+	default:
+		fmt.Fprintln(stderr, "SimpleAI: command", c.name, "needs a window")
+		return exitFailure
+	}
+	return exitOK
+}
+
+func (c *cliCommand) runList(app *App, stdout, stderr io.Writer) int {
+	services := app.services.All()
+
+	if c.json {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(services); err != nil {
+			fmt.Fprintln(stderr, "SimpleAI:", err)
+			return exitFailure
+		}
+		return exitOK
+	}
+
+	tw := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tNAME\tURL\tALIASES")
+	for _, s := range services {
+		label := s.Label
+		if s.Custom {
+			label += " (custom)"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", s.ID, label, s.URL, strings.Join(s.Aliases, ", "))
+	}
+	tw.Flush()
+	return exitOK
+}
+
+func (c *cliCommand) runResetPositions(app *App, stdout, stderr io.Writer) int {
+	ctx, cancel := context.WithTimeout(context.Background(), positionsLockTimeout)
+	defer cancel()
+
+	err := app.windowPosMgr.Update(ctx, app.windowPosPath, func() error {
+		if c.service == "" {
+			app.windowPosMgr.ClearPositions()
+		} else {
+			app.windowPosMgr.RemovePosition(profileTitle(app.services.WindowTitle(c.service), c.profile))
+		}
+		return nil
+	})
+	if report := app.windowPosMgr.Recovery(); report != nil && !report.Recovered() {
+		// Damaged file without backup: it was moved aside, resetting went on
+		fmt.Fprintln(stderr, "SimpleAI: warning:", report)
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, "SimpleAI: resetting window positions:", err)
+		return exitFailure
+	}
+
+	if c.service == "" {
+		fmt.Fprintln(stdout, "All window positions reset")
+	} else {
+		fmt.Fprintln(stdout, "Window position reset for", profileKey(c.service, c.profile))
+	}
+	return exitOK
+}
+
+func (c *cliCommand) runLayout(app *App, stdout, stderr io.Writer) int {
+	path := layoutsPath(app.configDir)
+
+	switch c.action {
+	case layoutList:
+		layouts, err := loadLayouts(path)
+		if err != nil {
+			fmt.Fprintln(stderr, "SimpleAI: reading layouts:", err)
+			return exitFailure
+		}
+		tw := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "NAME\tWINDOWS")
+		for _, layout := range layouts {
+			windows := make([]string, 0, len(layout.Windows))
+			for _, w := range layout.Windows {
+				windows = append(windows, profileKey(w.Service, w.Profile))
+			}
+			fmt.Fprintf(tw, "%s\t%s\n", layout.Name, strings.Join(windows, ", "))
+		}
+		tw.Flush()
+	case layoutSave:
+		layout, err := app.SaveLayout(c.layout)
+		if err != nil {
+			fmt.Fprintln(stderr, "SimpleAI:", err)
+			return exitFailure
+		}
+		fmt.Fprintf(stdout, "Layout %s saved with %d windows\n", layout.Name, len(layout.Windows))
+	case layoutDelete:
+		if err := app.DeleteLayout(c.layout); err != nil {
+			fmt.Fprintln(stderr, "SimpleAI:", err)
+			return exitFailure
+		}
+		fmt.Fprintln(stdout, "Layout", c.layout, "deleted")
+	default:
+		if err := app.OpenLayout(c.layout); err != nil {
+			fmt.Fprintln(stderr, "SimpleAI:", err)
+			return exitFailure
+		}
+	}
+	return exitOK
+}
+
+func (c *cliCommand) runArrange(stderr io.Writer) int {
+	arrangement := modWindowMemory.Arrangement(c.action)
+	var err error
+	if arrangement.IsSnap() {
+		err = snapWindow(profileKey(c.service, c.profile), arrangement)
+	} else {
+		err = arrangeWindows(arrangement)
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, "SimpleAI:", err)
+		return exitFailure
+	}
+	return exitOK
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseCommandLine(t *testing.T) {
+	services := NewServiceRegistry()
+	tests := []struct {
+		args []string
+		want *cliCommand
+		code int // exitCodeFor the parse error
+	}{
+		// Launcher, help and version
+		{args: nil, want: &cliCommand{name: cmdLauncher}},
+		{args: []string{"-psn_0_12345"}, want: &cliCommand{name: cmdLauncher}},
+		{args: []string{"--restore-session"}, want: &cliCommand{name: cmdLauncher, restoreSession: true}},
+		{args: []string{"--restore-session", "chatgpt"}, code: exitUsage},
+		{args: []string{"help"}, want: &cliCommand{name: cmdHelp}},
+		{args: []string{"--help"}, want: &cliCommand{name: cmdHelp}},
+		{args: []string{"-v"}, want: &cliCommand{name: cmdVersion}},
+		{args: []string{"--bogus"}, code: exitUsage},
+
+		// open and the bare service shortcut
+		{args: []string{"open", "chatgpt"}, want: &cliCommand{name: cmdOpen, service: "chatgpt"}},
+		{args: []string{"chatgpt"}, want: &cliCommand{name: cmdOpen, service: "chatgpt"}},
+		{args: []string{"GPT", "--new"}, want: &cliCommand{name: cmdOpen, service: "chatgpt", newWindow: true}},
+		{args: []string{"open", "--new", "claude"}, want: &cliCommand{name: cmdOpen, service: "claude", newWindow: true}},
+		{args: []string{"open", "claude", "--profile", "work"}, want: &cliCommand{name: cmdOpen, service: "claude", profile: "work"}},
+		{args: []string{"open", "claude", "--profile", "default"}, want: &cliCommand{name: cmdOpen, service: "claude"}},
+		{args: []string{"open", "claude", "--incognito", "--new"}, want: &cliCommand{name: cmdOpen, service: "claude", incognito: true, newWindow: true}},
+		{args: []string{"open", "claude", "--url", "https://claude.ai/new"}, want: &cliCommand{name: cmdOpen, service: "claude", url: "https://claude.ai/new"}},
+		{args: []string{"open", "claude", "--incognito", "--profile", "work"}, code: exitUsage},
+		{args: []string{"open", "claude", "--profile", "Work Stuff"}, code: exitUsage},
+		{args: []string{"open", "claude", "--url", "file:///etc/passwd"}, code: exitUsage},
+		{args: []string{"open", "claude", "--url"}, code: exitUsage},
+		{args: []string{"open", "claude", "--unknown"}, code: exitUsage},
+		{args: []string{"open"}, code: exitUsage},
+		{args: []string{"open", "claude", "chatgpt"}, code: exitUsage},
+		{args: []string{"open", "nosuchai"}, code: exitUnknownService},
+		{args: []string{"nosuchai"}, code: exitUnknownService},
+
+		// list, profiles, reset-positions
+		{args: []string{"list"}, want: &cliCommand{name: cmdList}},
+		{args: []string{"list", "--json"}, want: &cliCommand{name: cmdList, json: true}},
+		{args: []string{"list", "chatgpt"}, code: exitUsage},
+		{args: []string{"profiles"}, want: &cliCommand{name: cmdProfiles}},
+		{args: []string{"profiles", "work"}, code: exitUsage},
+		{args: []string{"reset-positions"}, want: &cliCommand{name: cmdResetPositions}},
+		{args: []string{"reset-positions", "gpt", "--profile", "work"}, want: &cliCommand{name: cmdResetPositions, service: "chatgpt", profile: "work"}},
+		{args: []string{"reset-positions", "--profile", "work"}, code: exitUsage},
+		{args: []string{"reset-positions", "chatgpt", "claude"}, code: exitUsage},
+		{args: []string{"reset-positions", "nosuchai"}, code: exitUnknownService},
+
+		// layout
+		{args: []string{"layout", "Research"}, want: &cliCommand{name: cmdLayout, action: layoutOpen, layout: "research"}},
+		{args: []string{"layout", "list"}, want: &cliCommand{name: cmdLayout, action: layoutList}},
+		{args: []string{"layout", "save", "research"}, want: &cliCommand{name: cmdLayout, action: layoutSave, layout: "research"}},
+		{args: []string{"layout", "delete", "research"}, want: &cliCommand{name: cmdLayout, action: layoutDelete, layout: "research"}},
+		{args: []string{"layout"}, code: exitUsage},
+		{args: []string{"layout", "save"}, code: exitUsage},
+		{args: []string{"layout", "save", "list"}, code: exitUsage},
+		{args: []string{"layout", "research", "extra"}, code: exitUsage},
+
+		// arrange
+		{args: []string{"arrange", "tile"}, want: &cliCommand{name: cmdArrange, action: "tile"}},
+		{args: []string{"arrange", "snap-left", "claude", "--profile", "work"}, want: &cliCommand{name: cmdArrange, action: "snap-left", service: "claude", profile: "work"}},
+		{args: []string{"arrange"}, code: exitUsage},
+		{args: []string{"arrange", "spiral"}, code: exitUsage},
+		{args: []string{"arrange", "tile", "claude"}, code: exitUsage},
+		{args: []string{"arrange", "snap-left"}, code: exitUsage},
+		{args: []string{"arrange", "snap-left", "nosuchai"}, code: exitUnknownService},
+	}
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			got, err := parseCommandLine(tt.args, services)
+			if code := exitCodeFor(err); code != tt.code {
+				t.Fatalf("exit code %d (%v), want %d", code, err, tt.code)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseCommandLine() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExitCodeFor(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"no error", nil, exitOK},
+		{"usage", &usageError{"bad flag"}, exitUsage},
+		{"unknown service", &unknownServiceError{"nosuchai"}, exitUnknownService},
+		{"wrapped unknown service", fmt.Errorf("layout: %w", &unknownServiceError{"nosuchai"}), exitUnknownService},
+		{"other", errors.New("disk full"), exitFailure},
+	}
+	for _, tt := range tests {
+		if got := exitCodeFor(tt.err); got != tt.want {
+			t.Errorf("%s: exitCodeFor(%v) = %d, want %d", tt.name, tt.err, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,233 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"SimpleAI/modWindowMemory"
+)
+
+// Single-instance control channel
+//
+// Every SimpleAI window listens on a per-user Unix domain socket named after its
+// instance key ("launcher" or the service ID) inside the control directory:
+//
+//	$XDG_RUNTIME_DIR/SimpleAI/<key>.sock
+//
+// A second launch of the same service connects to that socket, hands over its
+// arguments and exits; the running instance focuses itself through the Wails
+// runtime. Discovery only depends on the socket path, never on window titles.
+//
+// The protocol is one JSON request and one JSON response per connection, each
+// terminated by a newline.
+
+// Control commands
+const (
+	controlPing     = "ping"     // Health check, no side effects
+	controlActivate = "activate" // Focus the window, optionally navigate to URL
+	controlQuit     = "quit"     // Close the window (saves its position first)
+	controlGeometry = "geometry" // Report the current window geometry and the screens
+	controlPlace    = "place"    // Move and resize the window, then focus it
+)
+
+const (
+	controlDialTimeout = 500 * time.Millisecond
+	controlIOTimeout   = 2 * time.Second
+)
+
+// controlRequest is sent by a client to a running instance
+type controlRequest struct {
+	Command     string `json:"command"`
+	URL         string `json:"url,omitempty"`
+	KeepSession bool   `json:"keepSession,omitempty"` // quit: keep the window in the restorable session
+
+	Position *modWindowMemory.WindowPosition `json:"position,omitempty"` // place: target geometry
+}
+
+// controlResponse is the answer of a running instance
+type controlResponse struct {
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+
+	PID      int                             `json:"pid,omitempty"`      // ping: process ID of the instance
+	Position *modWindowMemory.WindowPosition `json:"position,omitempty"` // geometry: current geometry
+	Screens  []modWindowMemory.Rect          `json:"screens,omitempty"`  // geometry: screens, primary first
+}
+
+// controlHandler executes a request inside the running instance
+type controlHandler func(req controlRequest) controlResponse
+
+// controlServer accepts control connections for one instance
+type controlServer struct {
+	listener net.Listener
+	path     string // Socket path, published in the instance registry
+}
+
+var (
+	// errNoInstance is returned when no running instance owns the socket
+	errNoInstance = errors.New("no running instance")
+	// errAlreadyRunning is returned by listenControl if another instance owns the key
+	errAlreadyRunning = errors.New("instance already running")
+)
+
+// controlDir returns the per-user directory holding the control sockets.
+// $XDG_RUNTIME_DIR is preferred; platformRuntimeDir is the fallback.
+func controlDir() (string, error) {
+	dir := platformRuntimeDir()
+	if runtimeDir := os.Getenv("XDG_RUNTIME_DIR"); runtimeDir != "" {
+		dir = filepath.Join(runtimeDir, "SimpleAI")
+	}
+	// 0700: other users must not be able to talk to our windows
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+// controlSocketPath returns the socket path for an instance key
+func controlSocketPath(key string) (string, error) {
+	dir, err := controlDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, key+".sock"), nil
+}
+
+// sendControl sends a request to the instance owning key.
+// Returns errNoInstance if nothing is listening.
+func sendControl(key string, req controlRequest) (controlResponse, error) {
+	path, err := controlSocketPath(key)
+	if err != nil {
+		return controlResponse{}, err
+	}
+	return sendControlTo(path, req)
+}
+
+// activateControl asks the instance owning key to come to the front. Windows
+// only lets the foreground process raise windows, and that is the caller, not
+// the instance: its PID is asked for first and allowed to take the foreground.
+func activateControl(key string, req controlRequest) error {
+	if resp, err := sendControl(key, controlRequest{Command: controlPing}); err == nil && resp.PID != 0 {
+		allowForeground(resp.PID)
+	}
+	_, err := sendControl(key, req)
+	return err
+}
+
+// wsaeconnrefused is the Windows form of ECONNREFUSED
+const wsaeconnrefused = syscall.Errno(10061)
+
+// isStaleSocket reports whether a dial failed because nothing listens on the
+// socket: the file is gone, or it was left behind by a crashed process. Other
+// errors, like a timeout, may come from a live instance that is busy.
+func isStaleSocket(err error) bool {
+	return errors.Is(err, os.ErrNotExist) || errors.Is(err, syscall.ENOENT) ||
+		errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, wsaeconnrefused)
+}
+
+// sendControlTo sends a request to the socket at path. Returns errNoInstance
+// if nothing is listening.
+func sendControlTo(path string, req controlRequest) (controlResponse, error) {
+	return sendControlWithin(path, req, controlIOTimeout)
+}
+
+// sendControlWithin is sendControlTo with timeout bounding the request and the
+// wait for the answer
+func sendControlWithin(path string, req controlRequest, timeout time.Duration) (controlResponse, error) {
+	conn, err := net.DialTimeout("unix", path, min(controlDialTimeout, timeout))
+	if err != nil {
+		if isStaleSocket(err) {
+			return controlResponse{}, errNoInstance
+		}
+		return controlResponse{}, err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(timeout))
+
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return controlResponse{}, err
+	}
+
+	var resp controlResponse
+	if err := json.NewDecoder(bufio.NewReader(conn)).Decode(&resp); err != nil {
+		return controlResponse{}, fmt.Errorf("reading control response: %w", err)
+	}
+	if !resp.OK {
+		return resp, errors.New(resp.Error)
+	}
+	return resp, nil
+}
+
+// listenControl claims the control socket for key.
+// Returns errAlreadyRunning if a live instance already owns it; stale sockets
+// left behind by crashed processes are removed and reclaimed. An instance that
+// accepts the connection but doesn't answer in time counts as live: it may
+// still be starting up, and removing its socket would make it unreachable.
+func listenControl(key string) (*controlServer, error) {
+	path, err := controlSocketPath(key)
+	if err != nil {
+		return nil, err
+	}
+
+	for attempts := 0; attempts < 2; attempts++ {
+		listener, err := net.Listen("unix", path)
+		if err == nil {
+			return &controlServer{listener: listener, path: path}, nil
+		}
+
+		// Socket file exists - is anybody still listening?
+		if _, pingErr := sendControlTo(path, controlRequest{Command: controlPing}); !errors.Is(pingErr, errNoInstance) {
+			return nil, errAlreadyRunning
+		}
+		// Stale socket from a crashed instance
+		if rmErr := os.Remove(path); rmErr != nil && !os.IsNotExist(rmErr) {
+			return nil, err
+		}
+	}
+	return nil, fmt.Errorf("cannot claim control socket %s", path)
+}
+
+// serve handles connections until the server is closed. It is started as soon
+// as the socket is claimed, so pings are answered right away; the handler
+// waits until the window is up.
+func (s *controlServer) serve(handler controlHandler) {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return // Listener closed
+		}
+		go s.handle(conn, handler)
+	}
+}
+
+// handle processes a single request
+func (s *controlServer) handle(conn net.Conn, handler controlHandler) {
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(controlIOTimeout))
+
+	var req controlRequest
+	if err := json.NewDecoder(bufio.NewReader(conn)).Decode(&req); err != nil {
+		json.NewEncoder(conn).Encode(controlResponse{Error: "invalid request: " + err.Error()})
+		return
+	}
+
+	var resp controlResponse
+	if req.Command == controlPing {
+		resp = controlResponse{OK: true, PID: os.Getpid()}
+	} else {
+		resp = handler(req)
+	}
+	json.NewEncoder(conn).Encode(resp)
+}
+
+// close stops accepting connections. The listener removes its socket file.
+func (s *controlServer) close() {
+	s.listener.Close()
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"errors"
+	"net"
+	"os"
+	"testing"
+	"time"
+)
+
+// controlTestKey points the control directory at a temp dir and returns the
+// socket path of an instance key
+func controlTestKey(t *testing.T) (key, path string) {
+	t.Helper()
+	t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+	key = "chatgpt"
+	path, err := controlSocketPath(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key, path
+}
+
+func TestListenControlRunning(t *testing.T) {
+	key, _ := controlTestKey(t)
+
+	first, err := listenControl(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer first.close()
+	go first.serve(func(req controlRequest) controlResponse { return controlResponse{OK: true} })
+
+	if _, err := listenControl(key); !errors.Is(err, errAlreadyRunning) {
+		t.Fatalf("second listenControl() = %v, want errAlreadyRunning", err)
+	}
+	resp, err := sendControl(key, controlRequest{Command: controlPing})
+	if err != nil || resp.PID != os.Getpid() {
+		t.Errorf("ping = %+v, %v; want PID %d", resp, err, os.Getpid())
+	}
+}
+
+// TestListenControlSlow checks that an instance which accepts connections but
+// doesn't answer yet, like one still starting up, keeps its socket
+func TestListenControlSlow(t *testing.T) {
+	key, path := controlTestKey(t)
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		t.Skip("unix sockets not available:", err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close() // Never answers
+		}
+	}()
+
+	if _, err := listenControl(key); !errors.Is(err, errAlreadyRunning) {
+		t.Fatalf("listenControl() = %v, want errAlreadyRunning", err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("socket of the slow instance was removed: %v", err)
+	}
+}
+
+func TestListenControlStale(t *testing.T) {
+	key, path := controlTestKey(t)
+
+	// A socket file nobody listens on, as left behind by a crash
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		t.Skip("unix sockets not available:", err)
+	}
+	listener.(*net.UnixListener).SetUnlinkOnClose(false)
+	listener.Close()
+
+	if _, err := sendControl(key, controlRequest{Command: controlPing}); !errors.Is(err, errNoInstance) {
+		t.Errorf("ping of a stale socket = %v, want errNoInstance", err)
+	}
+	server, err := listenControl(key)
+	if err != nil {
+		t.Fatalf("stale socket not reclaimed: %v", err)
+	}
+	server.close()
+}
+
+// TestControlBeforeStartup checks that requests arriving before startup are
+// held until the window is up, while pings are answered right away
+func TestControlBeforeStartup(t *testing.T) {
+	key, _ := controlTestKey(t)
+
+	app := &App{started: make(chan struct{})}
+	server, err := listenControl(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.close()
+	go server.serve(app.handleControl)
+
+	if _, err := sendControl(key, controlRequest{Command: controlPing}); err != nil {
+		t.Fatalf("ping before startup: %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := sendControl(key, controlRequest{Command: "bogus"})
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		t.Fatalf("request handled before startup: %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	close(app.started)
+	if err := <-done; err == nil || err.Error() != "unknown command: bogus" {
+		t.Errorf("request after startup = %v, want unknown command", err)
+	}
+}
@@ -1,98 +1,52 @@
 import "./style.css";
 import "./app.css";
 import {
-  OpenNewInstance,
+  OpenProfileInstance,
   GetStartupService,
+  GetStartupURL,
   GetVersion,
-  SaveWindowPositionManual,
+  GetServices,
+  GetServiceProblems,
+  GetRunningInstances,
+  FocusInstance,
+  CloseInstance,
+  CloseAllInstances,
+  GetRememberLastURL,
+  SetRememberLastURL,
+  GetProfiles,
+  CreateProfile,
+  OpenIncognitoInstance,
+  GetPreviousSession,
+  RestorePreviousSession,
+  GetLayouts,
+  SaveLayout,
+  OpenLayout,
+  DeleteLayout,
+  ArrangeWindows,
+  SnapInstance,
 } from "../wailsjs/go/main/App";
 import { WindowSetTitle } from "../wailsjs/runtime/runtime";
 
-const aiServices = [
-  {
-    id: "chatgpt",
-    label: "ChatGPT",
-    url: "https://chatgpt.com",
-    description:
-      "<b>Most popular general-purpose AI</b><br><br>" +
-      "Powered by OpenAI's GPT-4 and GPT-5 models. Excels at creative writing, code generation, problem-solving, and conversational tasks. Fast response times with multimodal capabilities (text, images, voice).<br><br>" +
-      "<b>Best for:</b> Content creation, coding assistance, learning, brainstorming, and everyday tasks.",
-  },
-  {
-    id: "claude",
-    label: "Claude (Sonnet)",
-    url: "https://claude.ai",
-    description:
-      "<b>Deep reasoning and analysis</b><br><br>" +
-      "Anthropic's Claude Sonnet excels at nuanced understanding, long-context analysis (200K+ tokens), and following complex instructions. Strong ethical guidelines and safety focus. Better at structured analysis than creative tasks.<br><br>" +
-      "<b>Best for:</b> Document analysis, research synthesis, technical writing, code review, and ethical reasoning.",
-  },
-  {
-    id: "copilot",
-    label: "Copilot",
-    url: "https://copilot.microsoft.com",
-    description:
-      "<b>Microsoft ecosystem integration</b><br><br>" +
-      "Integrated with Microsoft 365 apps (Word, Excel, PowerPoint, Outlook). Combines GPT-4 with Bing search for grounded, up-to-date answers. Supports plugins and organizational data access with enterprise security.<br><br>" +
-      "<b>Best for:</b> Office productivity, business workflows, enterprise tasks, and real-time web research.",
-  },
-  {
-    id: "deepseek",
-    label: "Deepseek",
-    url: "https://chat.deepseek.com/",
-    description:
-      "<b>Advanced reasoning and coding</b><br><br>" +
-      "Chinese open-source model (DeepSeek-V3.2) with strong mathematical and coding capabilities. Features chain-of-thought reasoning and competitive performance at lower costs. Newly enhanced with agent capabilities and thinking modes.<br><br>" +
-      "<b>Best for:</b> Complex coding tasks, mathematical problem-solving, algorithmic challenges, and cost-effective AI access.",
-  },
-  {
-    id: "gemini",
-    label: "Gemini",
-    url: "https://gemini.google.com",
-    description:
-      "<b>Google's multimodal powerhouse</b><br><br>" +
-      "Latest Gemini 2.0 Flash and 2.5 Pro models with advanced multimodal understanding (text, images, video, audio). Deep integration with Google Workspace and Search. Excels at visual tasks, data analysis, and creative content.<br><br>" +
-      "<b>Best for:</b> Image generation, video analysis, Google Workspace tasks, research with web grounding, and visual creativity.",
-  },
-  {
-    id: "grok",
-    label: "Grok",
-    url: "https://grok.com",
-    description:
-      "<b>Real-time X/Twitter integration</b><br><br>" +
-      "X's AI with direct access to real-time X/Twitter data and trending topics. More conversational and less filtered than competitors. Developed by xAI with focus on truthfulness and current events awareness.<br><br>" +
-      "<b>Best for:</b> Social media insights, trending topics, current events, real-time news analysis, and uncensored conversations.",
-  },
-  {
-    id: "meta",
-    label: "Meta AI",
-    url: "https://www.meta.ai",
-    description:
-      "<b>Social-first AI assistant</b><br><br>" +
-      "Meta's LLaMA-powered AI integrated across Facebook, Instagram, and WhatsApp. Focuses on conversational AI, image generation, and social interactions. Privacy-conscious with transparent data usage policies.<br><br>" +
-      "<b>Best for:</b> Social media content, casual conversations, image creation, and Facebook/Instagram-related tasks.",
-  },
-  {
-    id: "perplexity",
-    label: "Perplexity",
-    url: "https://www.perplexity.ai",
-    description:
-      "<b>AI-powered research engine</b><br><br>" +
-      "Combines conversational AI with real-time web search and citations. Every answer includes source links for verification. Excels at research, fact-checking, and providing up-to-date information with transparency.<br><br>" +
-      "<b>Best for:</b> Academic research, fact-checking, current events, cited answers, and information discovery with sources.",
-  },
-];
+// Service list comes from the Go service registry (services.go)
+let aiServices = [];
 
 let currentService = "chatgpt";
 
 // Check if we should navigate to a specific service on startup
-GetStartupService().then((startupService) => {
+Promise.all([GetStartupService(), GetStartupURL(), GetServices()]).then(
+  ([startupService, startupURL, services]) => {
+    aiServices = services || [];
+    showStartPage(startupService, startupURL);
+  },
+);
+
+function showStartPage(startupService, startupURL) {
   if (startupService && startupService !== "") {
     // We were launched with a service argument, navigate to it
     const service = aiServices.find((s) => s.id === startupService);
     if (service) {
       WindowSetTitle(`SimpleAI - ${service.label}`);
-      window.location.href = service.url;
+      window.location.href = startupURL || service.url;
       return;
     }
   }
@@ -100,7 +54,7 @@ GetStartupService().then((startupService) => {
   // No startup service, show launcher
   showLauncher();
   WindowSetTitle("SimpleAI");
-});
+}
 
 function showLauncher() {
   // Show launcher with service buttons
@@ -173,6 +127,135 @@ function showLauncher() {
           title="Close">×</button>
         </div>
       </div>
+      <div id="profile-row" style="
+        --wails-draggable: no-drag;
+        display: flex;
+        gap: 5px;
+        justify-content: center;
+        align-items: center;
+        color: white;
+        font-size: 12px;
+        padding: 0 10px 5px 10px;
+      ">
+        Profile:
+        <select id="profile-select" style="
+          background: rgba(0, 212, 255, 0.1);
+          border: 1px solid #00d4ff;
+          color: white;
+          border-radius: 6px;
+          font-size: 12px;
+        "></select>
+        <input id="new-profile-name" placeholder="new profile" style="
+          display: none;
+          width: 100px;
+          background: rgba(0, 212, 255, 0.1);
+          border: 1px solid #00d4ff;
+          color: white;
+          border-radius: 6px;
+          font-size: 12px;
+        ">
+        <button id="btn-new-profile" title="New profile" style="
+          background: none;
+          border: 1px solid #00d4ff;
+          color: white;
+          border-radius: 6px;
+          cursor: pointer;
+          font-size: 12px;
+        ">+</button>
+        <label style="cursor: pointer; margin-left: 8px;" title="Throwaway session: nothing is saved">
+          <input type="checkbox" id="incognito-toggle" style="vertical-align: middle;">
+          Incognito
+        </label>
+      </div>
+      <div id="layout-row" style="
+        --wails-draggable: no-drag;
+        display: flex;
+        gap: 5px;
+        justify-content: center;
+        align-items: center;
+        color: white;
+        font-size: 12px;
+        padding: 0 10px 5px 10px;
+      ">
+        Layout:
+        <select id="layout-select" style="
+          background: rgba(0, 212, 255, 0.1);
+          border: 1px solid #00d4ff;
+          color: white;
+          border-radius: 6px;
+          font-size: 12px;
+        "></select>
+        <button id="btn-open-layout" title="Open and arrange the windows of this layout" style="
+          background: none;
+          border: 1px solid #00d4ff;
+          color: white;
+          border-radius: 6px;
+          cursor: pointer;
+          font-size: 12px;
+        ">Open</button>
+        <button id="btn-delete-layout" title="Delete layout" style="
+          background: none;
+          border: 1px solid #00d4ff;
+          color: white;
+          border-radius: 6px;
+          cursor: pointer;
+          font-size: 12px;
+        ">×</button>
+        <input id="new-layout-name" placeholder="layout name" style="
+          display: none;
+          width: 100px;
+          background: rgba(0, 212, 255, 0.1);
+          border: 1px solid #00d4ff;
+          color: white;
+          border-radius: 6px;
+          font-size: 12px;
+        ">
+        <button id="btn-save-layout" title="Save the open windows as a layout" style="
+          background: none;
+          border: 1px solid #00d4ff;
+          color: white;
+          border-radius: 6px;
+          cursor: pointer;
+          font-size: 12px;
+        ">Save current</button>
+      </div>
+      <div id="arrange-row" style="
+        --wails-draggable: no-drag;
+        display: flex;
+        gap: 5px;
+        justify-content: center;
+        align-items: center;
+        color: white;
+        font-size: 12px;
+        padding: 0 10px 5px 10px;
+      ">
+        Arrange:
+        ${[
+          ["tile", "Tile", "Share all screens between the open windows"],
+          ["side-by-side", "Side by side", "One column per window"],
+          ["grid", "Grid", "Rows and columns"],
+          ["stack", "Stack", "Overlapping cascade"],
+        ]
+          .map(
+            ([arrangement, label, title]) => `
+        <button data-arrange="${arrangement}" title="${title}" style="
+          background: none;
+          border: 1px solid #00d4ff;
+          color: white;
+          border-radius: 6px;
+          cursor: pointer;
+          font-size: 12px;
+        ">${label}</button>`,
+          )
+          .join("")}
+      </div>
+      <div id="service-problems" style="
+        --wails-draggable: no-drag;
+        display: none;
+        color: #ffb347;
+        font-size: 11px;
+        padding: 0 10px 5px 10px;
+      "></div>
       <div style="
         --wails-draggable: no-drag;
         display: flex;
@@ -188,6 +271,18 @@ function showLauncher() {
             position: relative;
             width: 150px;
           ">
+            <span id="running-${service.id}" title="Running" style="
+              display: none;
+              position: absolute;
+              top: 8px;
+              left: 8px;
+              width: 8px;
+              height: 8px;
+              border-radius: 50%;
+              background: #3ddc84;
+              box-shadow: 0 0 6px #3ddc84;
+              pointer-events: none;
+            "></span>
             <button id="btn-${service.id}" style="
               width: 100%;
               padding: 5px 5px;
@@ -201,7 +296,11 @@ function showLauncher() {
               white-space: nowrap;
             " onmouseover="this.style.background='rgba(0, 212, 255, 0.2)'" 
                onmouseout="this.style.background='rgba(0, 212, 255, 0.1)'">
-              ${service.label}
+              ${
+                service.icon
+                  ? `<img src="${service.icon}" alt="" style="width: 14px; height: 14px; vertical-align: -2px; margin-right: 4px;" onerror="this.remove()">`
+                  : ""
+              }${service.label}
             </button>
             <button id="info-${service.id}" style="
               --wails-draggable: no-drag;
@@ -229,6 +328,29 @@ function showLauncher() {
           )
           .join("")}
       </div>
+      <button id="btn-restore-session" style="
+        --wails-draggable: no-drag;
+        display: none;
+        margin: 8px auto 0;
+        background: none;
+        border: 1px solid #00d4ff;
+        color: white;
+        border-radius: 6px;
+        cursor: pointer;
+        font-size: 12px;
+        padding: 3px 10px;
+      "></button>
+      <div id="running-bar" style="
+        --wails-draggable: no-drag;
+        display: none;
+        flex-wrap: wrap;
+        gap: 5px;
+        justify-content: center;
+        align-items: center;
+        color: white;
+        font-size: 12px;
+        padding: 8px 10px;
+      "></div>
       </div>
     </div>
   `;
@@ -239,7 +361,11 @@ function showLauncher() {
         .getElementById(`btn-${service.id}`)
         .addEventListener("click", async () => {
           try {
-            await OpenNewInstance(service.id);
+            if (document.getElementById("incognito-toggle").checked) {
+              await OpenIncognitoInstance(service.id);
+            } else {
+              await OpenProfileInstance(service.id, selectedProfile());
+            }
           } catch (err) {
             console.error("Failed to open new instance:", err);
           }
@@ -273,6 +399,10 @@ function showLauncher() {
           modal.innerHTML = `
             <div style="font-size: 16px; font-weight: bold; margin-bottom: 10px;">${service.label}</div>
             <div style="font-size: 12px; margin-bottom: 10px; text-align: left; max-width: 600px;">${desc}</div>
+            <label style="font-size: 12px; margin-bottom: 10px; cursor: pointer;">
+              <input type="checkbox" id="remember-url" style="vertical-align: middle;">
+              Reopen last visited page
+            </label>
             <button id="close-modal" style="
               padding: 5px ;
               background: rgba(0, 212, 255, 0.2);
@@ -288,6 +418,16 @@ function showLauncher() {
 
           document.body.appendChild(modal);
 
+          const rememberURL = document.getElementById("remember-url");
+          GetRememberLastURL(service.id).then((enabled) => {
+            rememberURL.checked = enabled;
+          });
+          rememberURL.addEventListener("change", () => {
+            SetRememberLastURL(service.id, rememberURL.checked).catch((err) =>
+              console.error("Failed to save setting:", err),
+            );
+          });
+
           document
             .getElementById("close-modal")
             .addEventListener("click", () => {
@@ -303,6 +443,102 @@ function showLauncher() {
         });
     });
 
+    // Profile selection (separate logins per profile)
+    loadProfiles();
+    document
+      .getElementById("profile-select")
+      .addEventListener("change", refreshRunningInstances);
+    document.getElementById("btn-new-profile").addEventListener("click", () => {
+      const input = document.getElementById("new-profile-name");
+      if (input.style.display === "none") {
+        input.style.display = "inline-block";
+        input.focus();
+        return;
+      }
+      createProfileFromInput();
+    });
+    document
+      .getElementById("new-profile-name")
+      .addEventListener("keydown", (e) => {
+        if (e.key === "Enter") {
+          createProfileFromInput();
+        }
+      });
+
+    // Workspace layouts (several services arranged at once)
+    loadLayouts();
+    document.getElementById("btn-open-layout").addEventListener("click", () => {
+      const name = document.getElementById("layout-select").value;
+      if (name) {
+        OpenLayout(name)
+          .catch((err) => console.error("Failed to open layout:", err))
+          .finally(() => setTimeout(refreshRunningInstances, 500));
+      }
+    });
+    document
+      .getElementById("btn-delete-layout")
+      .addEventListener("click", () => {
+        const name = document.getElementById("layout-select").value;
+        if (name) {
+          DeleteLayout(name)
+            .catch((err) => console.error("Failed to delete layout:", err))
+            .finally(() => loadLayouts());
+        }
+      });
+    document.getElementById("btn-save-layout").addEventListener("click", () => {
+      const input = document.getElementById("new-layout-name");
+      if (input.style.display === "none") {
+        input.style.display = "inline-block";
+        input.focus();
+        return;
+      }
+      saveLayoutFromInput();
+    });
+    document
+      .getElementById("new-layout-name")
+      .addEventListener("keydown", (e) => {
+        if (e.key === "Enter") {
+          saveLayoutFromInput();
+        }
+      });
+
+    // Arrange all open service windows at once
+    document.querySelectorAll("[data-arrange]").forEach((btn) => {
+      btn.addEventListener("click", () =>
+        ArrangeWindows(btn.dataset.arrange).catch((err) =>
+          console.error("Failed to arrange windows:", err),
+        ),
+      );
+    });
+
+    // Show which service windows are open
+    refreshRunningInstances();
+    setInterval(refreshRunningInstances, 2000);
+
+    // Offer to reopen the windows of the previous session
+    document
+      .getElementById("btn-restore-session")
+      .addEventListener("click", () =>
+        RestorePreviousSession()
+          .catch((err) => console.error("Failed to restore session:", err))
+          .finally(() => setTimeout(refreshRunningInstances, 500)),
+      );
+
+    // Report malformed entries from services.json
+    GetServiceProblems().then((problems) => {
+      if (!problems || problems.length === 0) {
+        return;
+      }
+      const box = document.getElementById("service-problems");
+      box.textContent = "";
+      problems.forEach((problem) => {
+        const line = document.createElement("div");
+        line.textContent = `⚠ ${problem}`;
+        box.appendChild(line);
+      });
+      box.style.display = "block";
+    });
+
     // Add window control handlers
     document.getElementById("btn-minimize").addEventListener("click", () => {
       window.runtime.WindowMinimise();
@@ -314,20 +550,196 @@ function showLauncher() {
   });
 }
 
-// Save window position on resize/move events with debouncing
-let savePositionTimeout = null;
-const savePositionDebounced = () => {
-  if (savePositionTimeout) {
-    clearTimeout(savePositionTimeout);
+// selectedProfile returns the profile chosen in the launcher ("" = default)
+function selectedProfile() {
+  const select = document.getElementById("profile-select");
+  if (!select || select.value === "default") {
+    return "";
+  }
+  return select.value;
+}
+
+// loadProfiles fills the profile drop-down, keeping or setting the selection
+async function loadProfiles(selected) {
+  const select = document.getElementById("profile-select");
+  const current = selected || select.value || "default";
+  let profiles = ["default"];
+  try {
+    profiles = (await GetProfiles()) || profiles;
+  } catch (err) {
+    console.error("Failed to list profiles:", err);
+  }
+  select.innerHTML = profiles
+    .map((p) => `<option value="${p}">${p}</option>`)
+    .join("");
+  select.value = profiles.includes(current) ? current : "default";
+}
+
+// loadLayouts fills the layout selector
+async function loadLayouts(selected) {
+  const select = document.getElementById("layout-select");
+  const current = selected || select.value;
+  let layouts = [];
+  try {
+    layouts = (await GetLayouts()) || [];
+  } catch (err) {
+    console.error("Failed to list layouts:", err);
+  }
+  select.innerHTML = layouts
+    .map(
+      (l) =>
+        `<option value="${l.name}">${l.name} (${l.windows.length})</option>`,
+    )
+    .join("");
+  if (layouts.some((l) => l.name === current)) {
+    select.value = current;
   }
-  savePositionTimeout = setTimeout(async () => {
-    try {
-      await SaveWindowPositionManual();
-    } catch (err) {
-      console.error("Failed to save window position:", err);
+  document.getElementById("btn-open-layout").disabled = layouts.length === 0;
+  document.getElementById("btn-delete-layout").disabled = layouts.length === 0;
+}
+
+// saveLayoutFromInput stores the open windows under the entered name
+async function saveLayoutFromInput() {
+  const input = document.getElementById("new-layout-name");
+  const name = input.value.trim().toLowerCase();
+  if (name === "") {
+    input.style.display = "none";
+    return;
+  }
+  try {
+    await SaveLayout(name);
+    input.value = "";
+    input.title = "";
+    input.style.borderColor = "#00d4ff";
+    input.style.display = "none";
+    await loadLayouts(name);
+  } catch (err) {
+    input.title = String(err);
+    input.style.borderColor = "#ff3250";
+    console.error("Failed to save layout:", err);
+  }
+}
+
+// createProfileFromInput creates the profile typed into the name field
+async function createProfileFromInput() {
+  const input = document.getElementById("new-profile-name");
+  const name = input.value.trim().toLowerCase();
+  if (name === "") {
+    input.style.display = "none";
+    return;
+  }
+  try {
+    await CreateProfile(name);
+    input.value = "";
+    input.style.display = "none";
+    await loadProfiles(name);
+    refreshRunningInstances();
+  } catch (err) {
+    input.title = String(err);
+    input.style.borderColor = "#ff3250";
+    console.error("Failed to create profile:", err);
+  }
+}
+
+// refreshRunningInstances marks running services and lists focus/snap/close actions
+async function refreshRunningInstances() {
+  let instances = [];
+  try {
+    instances = (await GetRunningInstances()) || [];
+  } catch (err) {
+    console.error("Failed to list running instances:", err);
+  }
+  const windows = instances.filter((i) => i.service !== "");
+
+  aiServices.forEach((service) => {
+    const dot = document.getElementById(`running-${service.id}`);
+    if (dot) {
+      const running = windows.some(
+        (i) =>
+          i.service === service.id &&
+          i.profile === selectedProfile() &&
+          !i.incognito,
+      );
+      dot.style.display = running ? "block" : "none";
     }
-  }, 500); // Wait 500ms after last resize/move
-};
+  });
 
-window.addEventListener("resize", savePositionDebounced);
-// Note: 'move' event doesn't exist in standard DOM, position changes handled on close
+  const bar = document.getElementById("running-bar");
+  if (!bar) {
+    return;
+  }
+
+  const previous = (await GetPreviousSession()) || [];
+  const restore = document.getElementById("btn-restore-session");
+  restore.textContent = `Restore previous session (${previous.length})`;
+  restore.style.display = previous.length > 0 ? "block" : "none";
+
+  if (windows.length === 0) {
+    bar.style.display = "none";
+    return;
+  }
+
+  const actionStyle = `
+    background: none;
+    border: 1px solid #00d4ff;
+    color: white;
+    border-radius: 6px;
+    cursor: pointer;
+    font-size: 11px;
+    padding: 1px 6px;
+  `;
+  bar.innerHTML = `
+    ${windows
+      .map((i) => {
+        const service = aiServices.find((s) => s.id === i.service);
+        const name = service ? service.label : i.service;
+        let label = i.profile ? `${name} [${i.profile}]` : name;
+        if (i.incognito) {
+          label += " (incognito)";
+        }
+        const state = i.status === "running" ? "" : ` (${i.status})`;
+        const snap =
+          i.status === "running"
+            ? `
+        <button data-snap="left" data-pid="${i.pid}" style="${actionStyle}" title="Snap to left half">◧</button>
+        <button data-snap="right" data-pid="${i.pid}" style="${actionStyle}" title="Snap to right half">◨</button>`
+            : "";
+        return `
+      <span style="white-space: nowrap;">
+        ${label}${state}
+        <button data-focus="${i.pid}" style="${actionStyle}" title="Focus">↗</button>${snap}
+        <button data-close="${i.pid}" style="${actionStyle}" title="Close">×</button>
+      </span>`;
+      })
+      .join("")}
+    <button id="btn-close-all" style="${actionStyle}">Close all</button>
+  `;
+  bar.style.display = "flex";
+
+  bar.querySelectorAll("[data-focus]").forEach((btn) => {
+    btn.addEventListener("click", () =>
+      FocusInstance(Number(btn.dataset.focus)).catch((err) =>
+        console.error("Failed to focus instance:", err),
+      ),
+    );
+  });
+  bar.querySelectorAll("[data-snap]").forEach((btn) => {
+    btn.addEventListener("click", () =>
+      SnapInstance(Number(btn.dataset.pid), btn.dataset.snap).catch((err) =>
+        console.error("Failed to snap window:", err),
+      ),
+    );
+  });
+  bar.querySelectorAll("[data-close]").forEach((btn) => {
+    btn.addEventListener("click", () =>
+      CloseInstance(Number(btn.dataset.close))
+        .catch((err) => console.error("Failed to close instance:", err))
+        .finally(() => setTimeout(refreshRunningInstances, 500)),
+    );
+  });
+  document.getElementById("btn-close-all").addEventListener("click", () =>
+    CloseAllInstances()
+      .catch((err) => console.error("Failed to close instances:", err))
+      .finally(() => setTimeout(refreshRunningInstances, 500)),
+  );
+}
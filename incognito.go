@@ -0,0 +1,145 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"SimpleAI/modWindowMemory"
+)
+
+// Incognito windows
+//
+// "SimpleAI <service> --incognito" opens a window with throwaway WebView
+// storage in a fresh temporary directory. Nothing of the session is kept:
+// window geometry and the last page are not saved, and the directory is
+// overwritten and deleted when the window closes. Directories left behind by
+// crashed runs are removed on the next start of any SimpleAI instance.
+//
+// The owning process holds an exclusive lock on owner.lock in the directory
+// for as long as it runs. The kernel releases it when the process dies, so a
+// directory whose lock can be taken is stale, even if its PID has been reused
+// by another process since. Directories of older releases only have the
+// owner.pid marker and are checked by PID.
+
+const (
+	incognitoDirPattern = "SimpleAI-incognito-*"
+	incognitoOwnerFile  = "owner.pid"
+	incognitoLockFile   = "owner.lock"
+)
+
+// incognitoLock is held while this process uses its incognito directory
+var incognitoLock *modWindowMemory.FileLock
+
+// createIncognitoDir creates the temporary storage directory for this process
+func createIncognitoDir() (string, error) {
+	dir, err := os.MkdirTemp(os.TempDir(), incognitoDirPattern)
+	if err != nil {
+		return "", err
+	}
+	lock, err := modWindowMemory.TryLockFile(filepath.Join(dir, incognitoLockFile), true)
+	if err != nil {
+		os.RemoveAll(dir)
+		return "", err
+	}
+	owner := []byte(strconv.Itoa(os.Getpid()))
+	if err := os.WriteFile(filepath.Join(dir, incognitoOwnerFile), owner, 0600); err != nil {
+		lock.Unlock()
+		os.RemoveAll(dir)
+		return "", err
+	}
+	incognitoLock = lock
+	return dir, nil
+}
+
+// removeIncognitoDir securely deletes an incognito directory.
+// The WebView may release its files with a delay after the window closed
+// (WebView2 helper processes on Windows), so deletion is retried briefly.
+func removeIncognitoDir(dir string) {
+	// Windows can't delete a file that is still open
+	if incognitoLock != nil && filepath.Dir(incognitoLock.Path) == dir {
+		incognitoLock.Unlock()
+		incognitoLock = nil
+	}
+
+	for attempts := 0; attempts < 10; attempts++ {
+		secureWipe(dir)
+		if err := os.RemoveAll(dir); err == nil {
+			return
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+	println("[Incognito] Could not delete", dir, "- it will be removed on the next start")
+}
+
+// secureWipe overwrites every regular file below dir with zeros, so cookies
+// and cached pages can't be recovered from the freed disk blocks
+func secureWipe(dir string) {
+	zeros := make([]byte, 64*1024)
+	filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || !info.Mode().IsRegular() || info.Size() == 0 {
+			return nil
+		}
+		file, err := os.OpenFile(path, os.O_WRONLY, 0)
+		if err != nil {
+			return nil // Still in use, RemoveAll will be retried
+		}
+		defer file.Close()
+		for remaining := info.Size(); remaining > 0; {
+			n := int64(len(zeros))
+			if remaining < n {
+				n = remaining
+			}
+			if _, err := file.Write(zeros[:n]); err != nil {
+				return nil
+			}
+			remaining -= n
+		}
+		file.Sync()
+		return nil
+	})
+}
+
+// cleanupStaleIncognitoDirs removes incognito directories whose owning process
+// is gone (crash, kill, power loss)
+func cleanupStaleIncognitoDirs() {
+	dirs, err := filepath.Glob(filepath.Join(os.TempDir(), incognitoDirPattern))
+	if err != nil {
+		return
+	}
+	for _, dir := range dirs {
+		if incognitoDirInUse(dir) {
+			continue
+		}
+		println("[Incognito] Removing leftover directory", dir)
+		removeIncognitoDir(dir)
+	}
+}
+
+// incognitoDirInUse reports whether the process that created an incognito
+// directory is still running
+func incognitoDirInUse(dir string) bool {
+	lockPath := filepath.Join(dir, incognitoLockFile)
+	if _, err := os.Stat(lockPath); err == nil {
+		lock, err := modWindowMemory.TryLockFile(lockPath, true)
+		if err != nil {
+			return errors.Is(err, modWindowMemory.ErrLockContended) // Owner holds it
+		}
+		lock.Unlock()
+		return false
+	}
+
+	// Older release, or the owner hasn't locked the directory yet
+	data, err := os.ReadFile(filepath.Join(dir, incognitoOwnerFile))
+	if err != nil {
+		info, statErr := os.Stat(dir)
+		return statErr == nil && time.Since(info.ModTime()) < time.Minute
+	}
+	if pid, err := strconv.Atoi(strings.TrimSpace(string(data))); err == nil {
+		return pid == os.Getpid() || processAlive(pid)
+	}
+	return false
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"SimpleAI/modWindowMemory"
+)
+
+// incognitoTestDir creates an incognito directory in a private temp dir, as
+// left behind by a run that crashed
+func incognitoTestDir(t *testing.T, name string) string {
+	t.Helper()
+	dir := filepath.Join(os.TempDir(), "SimpleAI-incognito-"+name)
+	if err := os.Mkdir(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestCleanupStaleIncognitoDirs(t *testing.T) {
+	t.Setenv("TMPDIR", t.TempDir())
+
+	// Our own directory, locked for the lifetime of the process
+	own, err := createIncognitoDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeIncognitoDir(own)
+
+	// Crashed run whose PID now belongs to a running process (ours): the
+	// lock file is free, so the directory is stale regardless of the PID
+	reused := incognitoTestDir(t, "reused")
+	os.WriteFile(filepath.Join(reused, incognitoOwnerFile), []byte(strconv.Itoa(os.Getpid())), 0600)
+	os.WriteFile(filepath.Join(reused, incognitoLockFile), nil, 0644)
+
+	// Another live run holding its lock
+	live := incognitoTestDir(t, "live")
+	lock, err := modWindowMemory.TryLockFile(filepath.Join(live, incognitoLockFile), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lock.Unlock()
+
+	// Older release without a lock file, owner long gone
+	legacy := incognitoTestDir(t, "legacy")
+	os.WriteFile(filepath.Join(legacy, incognitoOwnerFile), []byte("999999999"), 0600)
+
+	// Owner still setting up: no marker yet, but fresh
+	fresh := incognitoTestDir(t, "fresh")
+
+	// No marker and old
+	abandoned := incognitoTestDir(t, "abandoned")
+	old := time.Now().Add(-time.Hour)
+	os.Chtimes(abandoned, old, old)
+
+	cleanupStaleIncognitoDirs()
+
+	tests := []struct {
+		dir  string
+		kept bool
+	}{
+		{own, true},
+		{reused, false},
+		{live, true},
+		{legacy, false},
+		{fresh, true},
+		{abandoned, false},
+	}
+	for _, tt := range tests {
+		_, err := os.Stat(tt.dir)
+		if kept := err == nil; kept != tt.kept {
+			t.Errorf("%s: kept = %v, want %v", filepath.Base(tt.dir), kept, tt.kept)
+		}
+	}
+}
+
+func TestRemoveIncognitoDirReleasesLock(t *testing.T) {
+	t.Setenv("TMPDIR", t.TempDir())
+
+	dir, err := createIncognitoDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if incognitoLock == nil {
+		t.Fatal("createIncognitoDir didn't lock the directory")
+	}
+	removeIncognitoDir(dir)
+	if incognitoLock != nil {
+		t.Error("lock still held after removeIncognitoDir")
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("directory still exists: %v", err)
+	}
+}
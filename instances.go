@@ -0,0 +1,255 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"SimpleAI/modWindowMemory"
+)
+
+// Running-instance registry
+//
+// Every SimpleAI process writes instance-<pid>.json into the control directory
+// (see control.go) on startup and removes it on shutdown. Files of crashed
+// processes are detected by a failed health check and cleaned up when the
+// registry is read.
+
+// Instance health states
+const (
+	instanceRunning      = "running"      // Control socket answers
+	instanceUnresponsive = "unresponsive" // Process alive, control socket doesn't answer
+	instanceNoControl    = "no-control"   // Process alive, opened with --new and has no control socket
+)
+
+// InstanceInfo describes one running SimpleAI process
+type InstanceInfo struct {
+	PID       int       `json:"pid"`
+	Key       string    `json:"key"`     // Instance key (see App.instanceKey)
+	Service   string    `json:"service"` // Service ID, empty for the launcher
+	Profile   string    `json:"profile"` // Profile name, empty for the default profile
+	Incognito bool      `json:"incognito"`
+	Label     string    `json:"label"` // Window title at startup
+	StartedAt time.Time `json:"startedAt"`
+	Endpoint  string    `json:"endpoint"` // Control socket path, empty if none
+	Status    string    `json:"status"`   // Health, filled in when listing
+}
+
+// instanceFilePrefix and suffix frame the PID in registry file names
+const (
+	instanceFilePrefix = "instance-"
+	instanceFileSuffix = ".json"
+)
+
+// instanceFilePath returns the registry file of a process
+func instanceFilePath(pid int) (string, error) {
+	dir, err := controlDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, fmt.Sprintf("%s%d%s", instanceFilePrefix, pid, instanceFileSuffix)), nil
+}
+
+// registerInstance writes the registry entry of this process
+func registerInstance(info InstanceInfo) error {
+	path, err := instanceFilePath(info.PID)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(info, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, data, 0600)
+}
+
+// unregisterInstance removes the registry entry of a process
+func unregisterInstance(pid int) {
+	if path, err := instanceFilePath(pid); err == nil {
+		os.Remove(path)
+	}
+}
+
+// listInstances returns all live instances sorted by start time and removes
+// entries of processes that no longer exist
+func listInstances() ([]InstanceInfo, error) {
+	dir, err := controlDir()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var instances []InstanceInfo
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasPrefix(name, instanceFilePrefix) || !strings.HasSuffix(name, instanceFileSuffix) {
+			continue
+		}
+		path := filepath.Join(dir, name)
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		var info InstanceInfo
+		if err := json.Unmarshal(data, &info); err != nil || info.PID == 0 {
+			os.Remove(path) // Unreadable entry, can't belong to a healthy instance
+			continue
+		}
+
+		info.Status = instanceHealth(info)
+		if info.Status == "" {
+			// Process is gone (crash, kill, logout) - clean up after it
+			os.Remove(path)
+			continue
+		}
+		instances = append(instances, info)
+	}
+
+	sort.Slice(instances, func(i, j int) bool {
+		return instances[i].StartedAt.Before(instances[j].StartedAt)
+	})
+	return instances, nil
+}
+
+// instanceHealth checks an instance. Returns "" if the process is gone.
+func instanceHealth(info InstanceInfo) string {
+	if info.Endpoint != "" {
+		if _, err := sendControlTo(info.Endpoint, controlRequest{Command: controlPing}); err == nil {
+			return instanceRunning
+		}
+	}
+	if !processAlive(info.PID) {
+		return ""
+	}
+	if info.Endpoint == "" {
+		return instanceNoControl
+	}
+	return instanceUnresponsive
+}
+
+// findInstance looks up a live instance by PID
+func findInstance(pid int) (InstanceInfo, error) {
+	instances, err := listInstances()
+	if err != nil {
+		return InstanceInfo{}, err
+	}
+	for _, info := range instances {
+		if info.PID == pid {
+			return info, nil
+		}
+	}
+	return InstanceInfo{}, fmt.Errorf("no running instance with PID %d", pid)
+}
+
+// sendToInstance sends a control request to a registered instance
+func sendToInstance(info InstanceInfo, req controlRequest) error {
+	_, err := queryInstance(info, req)
+	return err
+}
+
+// queryInstance sends a control request to a registered instance and returns its answer
+func queryInstance(info InstanceInfo, req controlRequest) (controlResponse, error) {
+	return queryInstanceWithin(info, req, controlIOTimeout)
+}
+
+// queryInstanceWithin is queryInstance with timeout bounding the request
+func queryInstanceWithin(info InstanceInfo, req controlRequest, timeout time.Duration) (controlResponse, error) {
+	if info.Endpoint == "" {
+		return controlResponse{}, errors.New("instance was opened with --new and cannot be controlled")
+	}
+	return sendControlWithin(info.Endpoint, req, timeout)
+}
+
+// siblingsTimeout bounds the geometry queries of siblingWindows altogether, so
+// busy instances don't hold up the window being opened
+const siblingsTimeout = time.Second
+
+// siblingWindows returns the geometry of the other running windows, oldest
+// first, so a new window can be placed next to them. Minimised windows and
+// instances that don't answer within siblingsTimeout are left out.
+func siblingWindows() []modWindowMemory.Rect {
+	instances, err := listInstances()
+	if err != nil {
+		return nil
+	}
+	var others []InstanceInfo
+	for _, info := range instances {
+		if info.PID != os.Getpid() && info.Status == instanceRunning {
+			others = append(others, info)
+		}
+	}
+	return queryWindows(others, siblingsTimeout)
+}
+
+// queryWindows asks instances for their window geometry in parallel and
+// returns the answers given within timeout, in the order of instances
+func queryWindows(instances []InstanceInfo, timeout time.Duration) []modWindowMemory.Rect {
+	type answer struct {
+		index  int
+		window *modWindowMemory.Rect
+	}
+	answers := make(chan answer, len(instances)) // Late answers don't block
+	for i, info := range instances {
+		go func() {
+			resp, err := queryInstanceWithin(info, controlRequest{Command: controlGeometry}, timeout)
+			if err != nil || resp.Position == nil || resp.Position.Minimised {
+				answers <- answer{index: i}
+				return
+			}
+			pos := resp.Position
+			answers <- answer{index: i, window: &modWindowMemory.Rect{X: pos.X, Y: pos.Y, Width: pos.Width, Height: pos.Height}}
+		}()
+	}
+
+	found := make([]*modWindowMemory.Rect, len(instances))
+	deadline := time.After(timeout)
+collect:
+	for range instances {
+		select {
+		case a := <-answers:
+			found[a.index] = a.window
+		case <-deadline:
+			println("[Instances] Not all instances reported their window in time")
+			break collect
+		}
+	}
+
+	var windows []modWindowMemory.Rect
+	for _, window := range found {
+		if window != nil {
+			windows = append(windows, *window)
+		}
+	}
+	return windows
+}
+
+// childEnv is the environment for spawned instances: the one SimpleAI was
+// started with, saved before a profile's overrides (see applyWebviewDataPath).
+// nil inherits the current environment.
+var childEnv []string
+
+// spawnInstance starts a new SimpleAI process with the given arguments
+func spawnInstance(args ...string) error {
+	exePath, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	cmd := exec.Command(exePath, args...)
+	cmd.Env = childEnv
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	// Reap the child when it exits so it doesn't linger as a zombie process
+	go cmd.Wait()
+	return nil
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"SimpleAI/modWindowMemory"
+)
+
+// TestQueryWindows checks that a new window isn't held up by an instance that
+// doesn't answer: the answers given in time are used, in instance order
+func TestQueryWindows(t *testing.T) {
+	key, fastPath := controlTestKey(t)
+	server, err := listenControl(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.close()
+	go server.serve(func(req controlRequest) controlResponse {
+		return controlResponse{OK: true, Position: &modWindowMemory.WindowPosition{X: 10, Y: 20, Width: 800, Height: 600}}
+	})
+
+	// An instance that accepts connections but never answers
+	slowPath, err := controlSocketPath("claude")
+	if err != nil {
+		t.Fatal(err)
+	}
+	listener, err := net.Listen("unix", slowPath)
+	if err != nil {
+		t.Skip("unix sockets not available:", err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	instances := []InstanceInfo{
+		{PID: 1, Endpoint: slowPath},
+		{PID: 2},
+		{PID: 3, Endpoint: fastPath},
+	}
+	start := time.Now()
+	windows := queryWindows(instances, 200*time.Millisecond)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("queryWindows took %v, want it bounded by the timeout", elapsed)
+	}
+	want := modWindowMemory.Rect{X: 10, Y: 20, Width: 800, Height: 600}
+	if len(windows) != 1 || windows[0] != want {
+		t.Errorf("queryWindows() = %v, want [%v]", windows, want)
+	}
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+
+	"SimpleAI/modWindowMemory"
+)
+
+// Workspace layouts
+//
+// A layout is a named set of service windows with a geometry for each, e.g.
+// "research" = Claude on the left, Perplexity on the right. Layouts are stored
+// in layouts.json next to windows.json.
+//
+// Saving asks every open service window for its geometry through the control
+// socket. Opening a layout moves the windows that are already open and starts
+// the missing ones; their layout geometry is written to windows.json first, so
+// the new processes restore it like any saved position.
+
+// layoutNamePattern restricts layout names to short, shell-friendly words
+var layoutNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,31}$`)
+
+// reservedLayoutNames are subcommands of "SimpleAI layout"
+var reservedLayoutNames = map[string]bool{
+	layoutSave:   true,
+	layoutList:   true,
+	layoutDelete: true,
+}
+
+// LayoutWindow is one service window of a layout
+type LayoutWindow struct {
+	Service  string                         `json:"service"`
+	Profile  string                         `json:"profile,omitempty"`
+	Position modWindowMemory.WindowPosition `json:"position"`
+}
+
+// Layout is a named set of service windows
+type Layout struct {
+	Name    string         `json:"name"`
+	Windows []LayoutWindow `json:"windows"`
+}
+
+// layoutsPath returns the path of layouts.json
+func layoutsPath(configDir string) string {
+	return filepath.Join(configDir, "SimpleAI", "layouts.json")
+}
+
+// validateLayoutName checks a layout name for saving
+func validateLayoutName(name string) error {
+	if !layoutNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid layout name %q: use up to 32 characters a-z, 0-9, '-' and '_'", name)
+	}
+	if reservedLayoutNames[name] {
+		return fmt.Errorf("%q is reserved and can't be used as a layout name", name)
+	}
+	return nil
+}
+
+// loadLayouts reads all layouts, sorted by name. A missing file is no error.
+func loadLayouts(path string) ([]Layout, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []Layout{}, nil
+		}
+		return nil, err
+	}
+	layouts := []Layout{}
+	if err := json.Unmarshal(data, &layouts); err != nil {
+		return nil, fmt.Errorf("%s: %w", filepath.Base(path), err)
+	}
+	sort.Slice(layouts, func(i, j int) bool { return layouts[i].Name < layouts[j].Name })
+	return layouts, nil
+}
+
+// saveLayouts writes all layouts
+func saveLayouts(path string, layouts []Layout) error {
+	data, err := json.MarshalIndent(layouts, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, data, 0644)
+}
+
+// findLayout returns the index of a layout, or -1
+func findLayout(layouts []Layout, name string) int {
+	for i, layout := range layouts {
+		if layout.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// getLayout loads a single layout by name
+func getLayout(path, name string) (Layout, error) {
+	layouts, err := loadLayouts(path)
+	if err != nil {
+		return Layout{}, err
+	}
+	i := findLayout(layouts, name)
+	if i < 0 {
+		return Layout{}, fmt.Errorf("unknown layout %q (run \"SimpleAI layout list\" to see all layouts)", name)
+	}
+	return layouts[i], nil
+}
+
+// captureLayout builds a layout from the service windows that are open now.
+// Incognito windows are left out, they can't be reopened with their session;
+// so are minimised windows, like when arranging.
+func captureLayout(name string) (Layout, error) {
+	instances, err := listInstances()
+	if err != nil {
+		return Layout{}, err
+	}
+
+	layout := Layout{Name: name, Windows: []LayoutWindow{}}
+	for _, info := range instances {
+		if info.Service == "" || info.Incognito || info.Status != instanceRunning {
+			continue
+		}
+		resp, err := queryInstance(info, controlRequest{Command: controlGeometry})
+		if err != nil || resp.Position == nil {
+			println("[Layouts] Skipping", info.Key, "- geometry not available")
+			continue
+		}
+		if resp.Position.Minimised || resp.Position.Width == 0 || resp.Position.Height == 0 {
+			// Iconified windows have no usable bounds (0x0 on Linux)
+			println("[Layouts] Skipping", info.Key, "- window is minimised")
+			continue
+		}
+		layout.Windows = append(layout.Windows, LayoutWindow{
+			Service:  info.Service,
+			Profile:  info.Profile,
+			Position: *resp.Position,
+		})
+	}
+
+	if len(layout.Windows) == 0 {
+		return Layout{}, errors.New("no open service windows to save")
+	}
+	return layout, nil
+}
+
+// storeLayout adds or replaces a layout in layouts.json
+func storeLayout(path string, layout Layout) error {
+	layouts, err := loadLayouts(path)
+	if err != nil {
+		return err
+	}
+	if i := findLayout(layouts, layout.Name); i >= 0 {
+		layouts[i] = layout
+	} else {
+		layouts = append(layouts, layout)
+	}
+	return saveLayouts(path, layouts)
+}
+
+// deleteLayout removes a layout from layouts.json
+func deleteLayout(path, name string) error {
+	layouts, err := loadLayouts(path)
+	if err != nil {
+		return err
+	}
+	i := findLayout(layouts, name)
+	if i < 0 {
+		return fmt.Errorf("unknown layout %q", name)
+	}
+	return saveLayouts(path, append(layouts[:i], layouts[i+1:]...))
+}
+
+// openLayout moves open windows of a layout into place and starts the others
+func (a *App) openLayout(layout Layout) error {
+	var errs []error
+	var start []LayoutWindow
+	positions := make(map[string]modWindowMemory.WindowPosition) // Of the windows to start, by position ID
+	for _, window := range layout.Windows {
+		service, ok := a.services.Lookup(window.Service)
+		if !ok {
+			errs = append(errs, fmt.Errorf("layout %s: unknown service %q", layout.Name, window.Service))
+			continue
+		}
+
+		pos := window.Position
+		key := profileKey(service.ID, window.Profile)
+		if _, err := sendControl(key, controlRequest{Command: controlPlace, Position: &pos}); err == nil {
+			continue // Already open, moved into place
+		}
+
+		positions[profileTitle(a.services.WindowTitle(service.ID), window.Profile)] = pos
+		start = append(start, window)
+	}
+
+	// Windows that are started open at their layout position. Positions of
+	// other windows are kept; a damaged windows.json is moved aside by the
+	// update, layouts still work.
+	if len(positions) > 0 {
+		ctx, cancel := context.WithTimeout(context.Background(), positionsLockTimeout)
+		defer cancel()
+		err := a.windowPosMgr.Update(ctx, a.windowPosPath, func() error {
+			for positionID, pos := range positions {
+				a.windowPosMgr.SetWindowPosition(positionID, pos)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	for _, window := range start {
+		args := []string{cmdOpen, window.Service}
+		if window.Profile != "" {
+			args = append(args, "--profile", window.Profile)
+		}
+		if err := spawnInstance(args...); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	wailsRuntime "github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// Last visited page per service
+//
+// Service windows show external sites, so the Wails bindings aren't available
+// there. WindowExecJS still works on any page, and the native message channel
+// of the webview is always present, so a small probe script reports
+// location.href back to Go as a Wails event. The last reported page is stored
+// in pages/<key>.json in the config directory and reopened on the next launch.
+
+// locationEvent is emitted by locationProbeJS with the current URL
+const locationEvent = "simpleai:location"
+
+// locationPollInterval is how often the current page is sampled
+const locationPollInterval = 2 * time.Second
+
+// loginGracePeriod is how long after startup a login page counts as a
+// redirect caused by the restored URL
+const loginGracePeriod = 20 * time.Second
+
+// locationProbeJS posts the current URL through the native webview channel in
+// the same "EE" event format the Wails runtime uses
+const locationProbeJS = `(function () {
+	var msg = "EE" + JSON.stringify({ name: "` + locationEvent + `", data: [window.location.href] });
+	if (window.chrome && window.chrome.webview && window.chrome.webview.postMessage) {
+		window.chrome.webview.postMessage(msg);
+	} else if (window.webkit && window.webkit.messageHandlers && window.webkit.messageHandlers.external) {
+		window.webkit.messageHandlers.external.postMessage(msg);
+	}
+})();`
+
+// loginSegments are path segments that identify sign-in pages, e.g.
+// /auth/login or /login.srf. Only whole segments match, so a conversation
+// named "oauth-docs" or a path like /authors is still remembered.
+var loginSegments = []string{
+	"login", "log-in", "signin", "sign-in", "sign_in", "signup", "sign-up",
+	"auth", "oauth", "oauth2", "authorize", "sso", "logout", "challenge",
+}
+
+// loginHostLabels are the first labels of sign-in hosts, e.g.
+// accounts.google.com, auth.openai.com or login.microsoftonline.com
+var loginHostLabels = []string{"accounts", "auth", "login", "signin", "sso"}
+
+// savedPage is the content of pages/<key>.json
+type savedPage struct {
+	URL     string    `json:"url"`
+	Updated time.Time `json:"updated"`
+}
+
+// locationTracker follows the page shown in a service window
+type locationTracker struct {
+	mu           sync.Mutex
+	path         string    // pages/<key>.json
+	homeURL      string    // Service start page
+	current      string    // Last reported URL
+	restoredURL  string    // Saved URL we started with, empty if none
+	startedAt    time.Time // For loginGracePeriod
+	persist      bool      // Write visited pages to disk
+	stop         chan struct{}
+	stopOnce     sync.Once
+	navigateHome func() // Leaves a login page reached through a restored URL
+}
+
+// pagePath returns the file that stores the last page of an instance key
+func pagePath(configDir, key string) string {
+	return filepath.Join(configDir, "SimpleAI", "pages", key+".json")
+}
+
+// loadSavedPage returns the stored last page of an instance key, or ""
+func loadSavedPage(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	var page savedPage
+	if json.Unmarshal(data, &page) != nil || !isTrackableURL(page.URL) {
+		return ""
+	}
+	return page.URL
+}
+
+// forgetSavedPage deletes the stored last page
+func forgetSavedPage(path string) {
+	os.Remove(path)
+}
+
+// isTrackableURL accepts http(s) pages of external sites, not the launcher assets
+func isTrackableURL(raw string) bool {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return false
+	}
+	return !strings.HasPrefix(u.Hostname(), "wails")
+}
+
+// isLoginURL reports whether a URL looks like a sign-in or auth page: its host
+// starts with a sign-in label or one of its path segments is a sign-in word
+func isLoginURL(raw string) bool {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return false
+	}
+	label, _, _ := strings.Cut(strings.ToLower(u.Hostname()), ".")
+	if slices.Contains(loginHostLabels, label) {
+		return true
+	}
+	for _, segment := range strings.Split(strings.ToLower(u.Path), "/") {
+		segment, _, _ = strings.Cut(segment, ".") // login.srf, signin.php
+		if slices.Contains(loginSegments, segment) {
+			return true
+		}
+	}
+	return false
+}
+
+// start begins polling the page location of the window
+func (t *locationTracker) start(ctx context.Context) {
+	wailsRuntime.EventsOn(ctx, locationEvent, func(data ...interface{}) {
+		if len(data) == 0 {
+			return
+		}
+		if href, ok := data[0].(string); ok {
+			t.report(href)
+		}
+	})
+
+	go func() {
+		ticker := time.NewTicker(locationPollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				wailsRuntime.WindowExecJS(ctx, locationProbeJS)
+			case <-t.stop:
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// report handles a URL posted by the probe script
+func (t *locationTracker) report(href string) {
+	if !isTrackableURL(href) {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if href == t.current {
+		return
+	}
+	t.current = href
+
+	if isLoginURL(href) {
+		// The saved page required a login (session expired) - start over at the
+		// service home page instead of keeping the user on the login redirect
+		if t.restoredURL != "" && time.Since(t.startedAt) < loginGracePeriod {
+			println("[Location] Restored page redirected to login, falling back to", t.homeURL)
+			t.restoredURL = ""
+			if t.persist {
+				forgetSavedPage(t.path)
+			}
+			if t.navigateHome != nil {
+				go t.navigateHome()
+			}
+		}
+		return // Never remember login pages
+	}
+
+	if t.persist {
+		t.save(href)
+	}
+}
+
+// save writes a page to disk; called with t.mu held
+func (t *locationTracker) save(href string) {
+	data, err := json.Marshal(savedPage{URL: href, Updated: time.Now()})
+	if err != nil {
+		return
+	}
+	if err := writeFileAtomic(t.path, data, 0600); err != nil {
+		println("[Location] Could not save last page:", err.Error())
+	}
+}
+
+// startURL returns the saved page the window should open, or ""
+func (t *locationTracker) startURL() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.restoredURL
+}
+
+// close stops polling
+func (t *locationTracker) close() {
+	t.stopOnce.Do(func() { close(t.stop) })
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestIsLoginURL(t *testing.T) {
+	tests := []struct {
+		url   string
+		login bool
+	}{
+		// Sign-in pages
+		{"https://chatgpt.com/auth/login", true},
+		{"https://auth.openai.com/log-in", true},
+		{"https://accounts.google.com/v3/signin/identifier?continue=https://gemini.google.com", true},
+		{"https://login.microsoftonline.com/common/oauth2/v2.0/authorize", true},
+		{"https://login.live.com/login.srf", true},
+		{"https://claude.ai/login?returnTo=%2Fnew", true},
+		{"https://www.perplexity.ai/api/auth/signin/google", true},
+		{"https://www.meta.ai/sso/", true},
+		{"https://grok.com/sign-in", true},
+		{"https://chat.deepseek.com/sign_in", true},
+		{"https://copilot.microsoft.com/challenge", true},
+		{"https://CLAUDE.AI/Logout", true},
+
+		// Regular pages that merely contain the words
+		{"https://chatgpt.com/c/67a1-oauth-docs", false},
+		{"https://www.perplexity.ai/search/who-is-the-author-of-dune", false},
+		{"https://claude.ai/chat/authors-guild-summary", false},
+		{"https://gemini.google.com/app/1234?q=login", false},
+		{"https://chatgpt.com/g/g-login-helper", false},
+		{"https://authority.example.com/chat", false},
+		{"https://chatgpt.com/", false},
+		{"https://claude.ai/new#login", false},
+	}
+	for _, tt := range tests {
+		if got := isLoginURL(tt.url); got != tt.login {
+			t.Errorf("isLoginURL(%q) = %v, want %v", tt.url, got, tt.login)
+		}
+	}
+}
+
+func TestLocationReport(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "chatgpt.json")
+	tracker := &locationTracker{path: path, homeURL: "https://chatgpt.com", persist: true}
+
+	tracker.report("https://chatgpt.com/c/67a1-oauth-docs")
+	if got := loadSavedPage(path); got != "https://chatgpt.com/c/67a1-oauth-docs" {
+		t.Errorf("saved page %q after a conversation", got)
+	}
+	tracker.report("https://chatgpt.com/auth/login")
+	if got := loadSavedPage(path); got != "https://chatgpt.com/c/67a1-oauth-docs" {
+		t.Errorf("saved page %q after a login page, want the conversation kept", got)
+	}
+}
@@ -3,9 +3,10 @@ package main
 import (
 	"context"
 	"embed"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
-	"strings"
 
 	"github.com/wailsapp/wails/v2"
 	"github.com/wailsapp/wails/v2/pkg/options"
@@ -18,26 +19,88 @@ import (
 var assets embed.FS
 
 func main() {
-	// Check for command-line arguments
-	var startupService string
+	// Command-line output must reach the terminal even for GUI builds
 	if len(os.Args) > 1 {
-		startupService = strings.ToLower(os.Args[1])
+		attachParentConsole()
 	}
 
 	// Create an instance of the app structure
 	app := NewApp()
+
+	// Parse command-line arguments (see cli.go)
+	cmd, err := parseCommandLine(os.Args[1:], app.services)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "SimpleAI:", err)
+		if exitCodeFor(err) == exitUsage {
+			fmt.Fprint(os.Stderr, "\n", usageText)
+		}
+		os.Exit(exitCodeFor(err))
+	}
+	if !cmd.opensWindow() {
+		os.Exit(cmd.run(app, os.Stdout, os.Stderr))
+	}
+
+	// Reopen the windows of the previous session (see session.go)
+	if cmd.restoreSession {
+		if _, err := restoreSession(app.configDir, app.services); err != nil {
+			fmt.Fprintln(os.Stderr, "SimpleAI: restoring session:", err)
+		}
+	}
+
+	startupService := cmd.service
 	app.startupService = startupService
+	app.startupURL = cmd.url
+	app.profile = cmd.profile
+	app.incognito = cmd.incognito
+
+	// Remove throwaway storage of incognito windows that crashed (see incognito.go)
+	go cleanupStaleIncognitoDirs()
+
+	// Single instance per service: hand over to a running window if there is one (see control.go)
+	activate := controlRequest{Command: controlActivate, URL: cmd.url}
+	if !cmd.newWindow && !cmd.incognito {
+		if err := activateControl(app.instanceKey(), activate); err == nil {
+			os.Exit(exitOK)
+		}
+	}
+	control, err := listenControl(app.instanceKey())
+	switch {
+	case err == nil:
+		app.control = control
+		go control.serve(app.handleControl)
+	case errors.Is(err, errAlreadyRunning) && !cmd.newWindow:
+		// Another launch of the same service won the race - hand over to it
+		if err := activateControl(app.instanceKey(), activate); err == nil {
+			os.Exit(exitOK)
+		}
+		println("[Control] Running instance did not respond, opening a new window")
+	case errors.Is(err, errAlreadyRunning):
+		// --new: this window runs without a control socket
+	default:
+		println("[Control] Single-instance control unavailable:", err.Error())
+	}
 
 	// Launcher gets frameless window for custom title bar
 	frameless := startupService == ""
 
-	// Get user data directory for WebView storage (cookies, sessions, cache)
-	userDataDir, err := os.UserCacheDir()
-	if err != nil {
-		// Fallback to config dir if cache dir fails
-		userDataDir, _ = os.UserConfigDir()
+	// WebView storage (cookies, sessions, cache) - separate per profile (see profiles.go)
+	webviewDataPath := webviewDataPath(app.profile)
+	if app.incognito {
+		dir, err := createIncognitoDir()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "SimpleAI: cannot create incognito storage:", err)
+			os.Exit(exitFailure)
+		}
+		app.incognitoDir = dir
+		webviewDataPath = filepath.Join(dir, "webview")
+		if !applyWebviewDataPath(webviewDataPath) {
+			removeIncognitoDir(dir)
+			fmt.Fprintln(os.Stderr, "SimpleAI: incognito windows are not supported on this platform")
+			os.Exit(exitFailure)
+		}
+	} else if app.profile != "" && !applyWebviewDataPath(webviewDataPath) {
+		println("[Profiles] Separate WebView storage is not supported on this platform, profile shares the default login")
 	}
-	webviewDataPath := filepath.Join(userDataDir, "SimpleAI", "webview")
 
 	// Create application with options
 	err = wails.Run(&options.App{
@@ -54,12 +117,9 @@ func main() {
 		OnStartup:        app.startup,
 		OnShutdown:       app.shutdown,
 		OnBeforeClose: func(ctx context.Context) bool {
-			// Save window position
-			app.windowPosMgr.SavePosition(ctx, app.GetWindowTitle(), app.windowPosPath)
-
-			// Save current URL if on an AI service page
-			// Note: We can't execute JavaScript in external sites due to CSP,
-			// but the URL will be saved on the next launch when user returns
+			// Save window position and update the session
+			// The current page of service windows is saved whenever it changes (see location.go)
+			app.beforeClose(ctx)
 			return false
 		},
 		Bind: []interface{}{
@@ -75,7 +135,13 @@ func main() {
 		},
 	})
 
+	// Throwaway storage is deleted once the WebView is gone
+	if app.incognitoDir != "" {
+		removeIncognitoDir(app.incognitoDir)
+	}
+
 	if err != nil {
 		println("Error:", err.Error())
+		os.Exit(exitFailure)
 	}
 }
@@ -0,0 +1,167 @@
+package modWindowMemory
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Arranging windows
+//
+// Arrange lays out a set of windows on the screens. It only computes the new
+// bounds; the application applies them to each window (SimpleAI sends them to
+// the running instances, which move themselves with ApplyPosition and thus the
+// platform backends).
+//
+// All arrangements except tile use one screen: the one most of the windows
+// are on, the primary screen on ties. Snapping uses the screen of the window.
+
+// Arrangement selects how Arrange lays out windows
+type Arrangement string
+
+// Arrangements
+const (
+	ArrangeTile       Arrangement = "tile"         // Grid on every screen, windows shared between the screens
+	ArrangeSideBySide Arrangement = "side-by-side" // One column per window
+	ArrangeGrid       Arrangement = "grid"         // Rows and columns
+	ArrangeStack      Arrangement = "stack"        // Overlapping cascade of equal windows
+	ArrangeSnapLeft   Arrangement = "snap-left"    // Left half of the screen
+	ArrangeSnapRight  Arrangement = "snap-right"   // Right half of the screen
+)
+
+// Arrangements lists all arrangements in the order they are offered
+var Arrangements = []Arrangement{ArrangeTile, ArrangeSideBySide, ArrangeGrid, ArrangeStack, ArrangeSnapLeft, ArrangeSnapRight}
+
+// stackShare is the part of the screen width and height a stacked window gets
+const stackShare = 2.0 / 3.0
+
+// ParseArrangement checks an arrangement name
+func ParseArrangement(name string) (Arrangement, error) {
+	for _, arrangement := range Arrangements {
+		if Arrangement(name) == arrangement {
+			return arrangement, nil
+		}
+	}
+	names := make([]string, len(Arrangements))
+	for i, arrangement := range Arrangements {
+		names[i] = string(arrangement)
+	}
+	return "", fmt.Errorf("unknown arrangement %q (use %s)", name, strings.Join(names, ", "))
+}
+
+// IsSnap reports whether the arrangement snaps a single window
+func (a Arrangement) IsSnap() bool {
+	return a == ArrangeSnapLeft || a == ArrangeSnapRight
+}
+
+// Arrange returns new bounds for windows, given their current bounds in the
+// order they should be laid out, on screens (primary first). Returns nil
+// without windows or screens.
+func Arrange(arrangement Arrangement, windows []Rect, screens []Rect) []Rect {
+	if len(windows) == 0 || len(screens) == 0 {
+		return nil
+	}
+	count := len(windows)
+	screen := mainScreen(windows, screens)
+
+	switch arrangement {
+	case ArrangeTile:
+		return tileScreens(count, screens)
+	case ArrangeSideBySide:
+		return gridCells(count, 1, count, screen)
+	case ArrangeGrid:
+		cols := int(math.Ceil(math.Sqrt(float64(count))))
+		return gridCells(count, (count+cols-1)/cols, cols, screen)
+	case ArrangeStack:
+		return stackCells(count, screen)
+	case ArrangeSnapLeft, ArrangeSnapRight:
+		half := screen
+		half.Width = screen.Width / 2
+		if arrangement == ArrangeSnapRight {
+			half.X = screen.X + screen.Width - half.Width
+		}
+		bounds := make([]Rect, count)
+		for i := range bounds {
+			bounds[i] = half
+		}
+		return bounds
+	}
+	return nil
+}
+
+// mainScreen returns the screen most windows are on, the first one on ties
+func mainScreen(windows []Rect, screens []Rect) Rect {
+	counts := make([]int, len(screens))
+	for _, window := range windows {
+		if window.Width == 0 || window.Height == 0 {
+			continue // Minimised, no usable bounds
+		}
+		screen, _ := screenOf(window, screens)
+		for i, s := range screens {
+			if s == screen {
+				counts[i]++
+				break
+			}
+		}
+	}
+	best := 0
+	for i, n := range counts {
+		if n > counts[best] {
+			best = i
+		}
+	}
+	return screens[best]
+}
+
+// gridCells splits an area into rows of cols cells for count windows. The
+// last row may have fewer windows; they share its full width.
+func gridCells(count, rows, cols int, area Rect) []Rect {
+	cells := make([]Rect, 0, count)
+	for row := 0; row < rows; row++ {
+		inRow := min(cols, count-row*cols)
+		top := area.Y + row*area.Height/rows
+		bottom := area.Y + (row+1)*area.Height/rows
+		for col := 0; col < inRow; col++ {
+			left := area.X + col*area.Width/inRow
+			right := area.X + (col+1)*area.Width/inRow
+			cells = append(cells, Rect{X: left, Y: top, Width: right - left, Height: bottom - top})
+		}
+	}
+	return cells
+}
+
+// tileScreens shares count windows between the screens, the primary screen
+// taking the extra ones, and lays them out as a grid on each screen
+func tileScreens(count int, screens []Rect) []Rect {
+	cells := make([]Rect, 0, count)
+	for i, screen := range screens {
+		n := count / len(screens)
+		if i < count%len(screens) {
+			n++
+		}
+		if n == 0 {
+			continue
+		}
+		cols := int(math.Ceil(math.Sqrt(float64(n))))
+		cells = append(cells, gridCells(n, (n+cols-1)/cols, cols, screen)...)
+	}
+	return cells
+}
+
+// stackCells cascades equal windows from the top left corner of the screen.
+// The step shrinks if the cascade wouldn't fit.
+func stackCells(count int, screen Rect) []Rect {
+	width := int(float64(screen.Width) * stackShare)
+	height := int(float64(screen.Height) * stackShare)
+	stepX, stepY := cascadeStep, cascadeStep
+	if count > 1 {
+		stepX = min(stepX, (screen.Width-width)/(count-1))
+		stepY = min(stepY, (screen.Height-height)/(count-1))
+	}
+
+	cells := make([]Rect, count)
+	for i := range cells {
+		cells[i] = Rect{X: screen.X + i*stepX, Y: screen.Y + i*stepY, Width: width, Height: height}
+	}
+	return cells
+}
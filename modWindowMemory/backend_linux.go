@@ -0,0 +1,182 @@
+//go:build linux
+// +build linux
+
+package modWindowMemory
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Window backends
+//
+// The Wails runtime can't reliably report window geometry on Linux (see
+// windowposition_linux.go), so it is read and set through the display server.
+// How depends on the session:
+//
+//	x11       built-in X11 client (X11 sessions, and GTK forced onto XWayland)
+//	xdotool   xdotool/xprop, if the X server can't be reached natively
+//	sway      sway/i3 IPC socket ($SWAYSOCK, or $I3SOCK)
+//	hyprland  Hyprland socket ($HYPRLAND_INSTANCE_SIGNATURE)
+//
+// Other Wayland compositors offer no way to read or move another client's
+// window. Without a backend only the Wails runtime is used: sizes are
+// restored, positions usually aren't. WindowBackend reports which backend
+// was detected, or ErrNoBackend and why.
+//
+// Backends act on the window of a process, found by its PID; see
+// windowposition_linux.go for why.
+
+// windowBackend reads and changes the window of a process through the display server
+type windowBackend interface {
+	// name identifies the backend in logs
+	name() string
+	// physicalPixels reports whether geometry and screens are in physical
+	// pixels (X11) rather than logical, scaled ones (Wayland compositors)
+	physicalPixels() bool
+	// geometry returns the client area of the window in desktop coordinates
+	geometry(pid int) (Rect, error)
+	// moveResize sets the parts of the client area selected by mask
+	// (x11ConfigX, x11ConfigY, x11ConfigWidth, x11ConfigHeight)
+	moveResize(pid int, mask uint16, r Rect) error
+	// state reads the maximised, fullscreen and hidden (minimised) state
+	state(pid int) (maximised, fullscreen, hidden bool, err error)
+	// setState asks for the maximised or fullscreen state
+	setState(pid int, maximised, fullscreen bool) error
+	// activate raises and focuses the window
+	activate(pid int) error
+	// desktop returns the virtual desktop of the window and the number of
+	// desktops, or errNoDesktop
+	desktop(pid int) (desktop, count int, err error)
+	// setDesktop moves the window to a virtual desktop
+	setDesktop(pid int, desktop int) error
+	// screens returns the outputs with their offsets, primary first, or nil
+	screens() []Rect
+}
+
+// errTiled is returned when a tiling compositor manages the window's geometry
+var errTiled = errors.New("window is tiled - its geometry is managed by the compositor")
+
+// errNoDesktop is returned when the window isn't on one numbered virtual
+// desktop: no EWMH desktops (or a compositor with named workspaces), or a
+// window shown on all desktops
+var errNoDesktop = errors.New("window is not on a virtual desktop")
+
+// detected holds the backend chosen on first use
+var detected struct {
+	once    sync.Once
+	backend windowBackend
+	err     error
+}
+
+// linuxBackend returns the backend for this session, or ErrNoBackend.
+// Detection runs once; the reason for a missing backend is logged.
+func linuxBackend() (windowBackend, error) {
+	detected.once.Do(func() {
+		detected.backend, detected.err = detectBackend()
+		if detected.err != nil {
+			println("[WindowPos]", detected.err.Error())
+		} else {
+			println("[WindowPos] Window backend:", detected.backend.name())
+		}
+	})
+	return detected.backend, detected.err
+}
+
+// detectBackend picks a backend from the session environment
+func detectBackend() (windowBackend, error) {
+	if waylandSession() {
+		if socket := os.Getenv("SWAYSOCK"); socket != "" {
+			return &swayBackend{socket: socket}, nil
+		}
+		if socket := os.Getenv("I3SOCK"); socket != "" {
+			return &swayBackend{socket: socket}, nil
+		}
+		if signature := os.Getenv("HYPRLAND_INSTANCE_SIGNATURE"); signature != "" {
+			return &hyprlandBackend{socket: hyprlandSocket(signature)}, nil
+		}
+		compositor := os.Getenv("XDG_CURRENT_DESKTOP")
+		if compositor == "" {
+			compositor = "this Wayland compositor"
+		}
+		return nil, &noBackendError{"no window backend for " + compositor +
+			" (supported on Wayland: sway, i3, Hyprland); set GDK_BACKEND=x11 to use XWayland"}
+	}
+
+	if _, err := x11Display(); err == nil {
+		return x11Backend{}, nil
+	}
+	if _, err := exec.LookPath("xdotool"); err == nil {
+		return xdotoolBackend{}, nil
+	}
+	return nil, &noBackendError{"no window backend: X server not reachable and xdotool not installed"}
+}
+
+// waylandSession reports whether GTK windows of this process are Wayland
+// windows. With GDK_BACKEND=x11 they go through XWayland instead.
+func waylandSession() bool {
+	if os.Getenv("WAYLAND_DISPLAY") == "" {
+		return false
+	}
+	gdkBackend := os.Getenv("GDK_BACKEND")
+	return gdkBackend == "" || strings.HasPrefix(gdkBackend, "wayland")
+}
+
+// noBackendError explains why no backend was found; it matches ErrNoBackend
+type noBackendError struct {
+	reason string
+}
+
+func (e *noBackendError) Error() string {
+	return e.reason
+}
+
+func (e *noBackendError) Is(target error) bool {
+	return target == ErrNoBackend
+}
+
+// WindowBackend returns the name of the backend that reads and moves windows
+// (Linux implementation): "x11", "xdotool", "sway" or "hyprland". Returns an
+// error matching ErrNoBackend that explains why if there is none.
+func WindowBackend() (string, error) {
+	backend, err := linuxBackend()
+	if err != nil {
+		return "", err
+	}
+	return backend.name(), nil
+}
+
+// orderOutputs sorts compositor outputs, which have no primary output, into a
+// stable order: the output at the desktop origin (or the top left one) first
+// as primary, the others by position. Focus must not change the order, since
+// it identifies the display configuration (see displayFingerprint).
+func orderOutputs(screens []Rect) []Rect {
+	if len(screens) == 0 {
+		return nil
+	}
+	sort.Slice(screens, func(i, j int) bool {
+		if screens[i].X != screens[j].X {
+			return screens[i].X < screens[j].X
+		}
+		return screens[i].Y < screens[j].Y
+	})
+	primary := 0
+	for i, s := range screens {
+		if s.X <= 0 && s.Y <= 0 && s.X+s.Width > 0 && s.Y+s.Height > 0 {
+			primary = i // At the origin
+			break
+		}
+		if s.Y < screens[primary].Y || (s.Y == screens[primary].Y && s.X < screens[primary].X) {
+			primary = i // Top left so far
+		}
+	}
+
+	ordered := make([]Rect, 0, len(screens))
+	ordered = append(ordered, screens[primary])
+	ordered = append(ordered, screens[:primary]...)
+	return append(ordered, screens[primary+1:]...)
+}
@@ -0,0 +1,130 @@
+package modWindowMemory
+
+import "math"
+
+// Desktop coordinates on Windows and macOS
+//
+// The manager works in one coordinate space per platform: desktop coordinates
+// with the primary screen at (0, 0), the same space the screens are reported
+// in (platformScreens, geometry_*.go). Wails doesn't use that space for
+// everything:
+//
+//	Windows  SetPosition is relative to the work area of the window's monitor,
+//	         Position is absolute; both in physical pixels. Sizes are in
+//	         logical pixels (DIP) of the window's monitor.
+//	macOS    SetPosition and Position are relative to the visible frame of
+//	         the window's screen (top left, y down); everything in points.
+//
+// desktopWindow wraps the Wails runtime and converts between the two, so a
+// window can be put on any screen, not just the one it is on. Desktop
+// coordinates are physical pixels on Windows (monitor rects are physical,
+// and a monitor's DPI only applies to windows on it) and points on macOS.
+
+// platformScreen is a screen as reported by the platform
+type platformScreen struct {
+	Work  Rect    // Work area (without taskbar, menu bar or Dock) in desktop coordinates
+	Scale float64 // Physical / logical pixels, 0 if unknown
+}
+
+// desktopWindow adapts the Wails window geometry to desktop coordinates
+type desktopWindow struct {
+	WindowRuntime
+
+	screens  func() []platformScreen // Primary first
+	relative bool                    // Position reads are relative too (macOS)
+	physical bool                    // Desktop coordinates are physical pixels, sizes are scaled (Windows)
+
+	// current returns the index of the window's screen in screens, -1 if
+	// unknown. Only used with relative reads, where the position alone
+	// doesn't tell.
+	current func() int
+}
+
+// on returns the screen the window is on; ok is false if it is unknown
+func (w desktopWindow) on() (screen platformScreen, ok bool) {
+	screens := w.screens()
+	if w.relative {
+		if i := w.current(); i >= 0 && i < len(screens) {
+			return screens[i], true
+		}
+		return platformScreen{}, false
+	}
+
+	x, y := w.WindowRuntime.Position()
+	width, height := w.WindowRuntime.Size()
+	work := make([]Rect, len(screens))
+	for i, s := range screens {
+		work[i] = s.Work
+	}
+	found, ok := screenOf(Rect{X: x, Y: y, Width: width, Height: height}, work)
+	for _, s := range screens {
+		if ok && s.Work == found {
+			return s, true
+		}
+	}
+	return platformScreen{}, false
+}
+
+func (w desktopWindow) Position() (x, y int) {
+	x, y = w.WindowRuntime.Position()
+	if w.relative {
+		if screen, ok := w.on(); ok {
+			x, y = x+screen.Work.X, y+screen.Work.Y
+		}
+	}
+	return x, y
+}
+
+// SetPosition converts to the work area of the screen the window is on
+// before the move; Wails finds the same screen
+func (w desktopWindow) SetPosition(x, y int) {
+	if screen, ok := w.on(); ok {
+		x, y = x-screen.Work.X, y-screen.Work.Y
+	}
+	w.WindowRuntime.SetPosition(x, y)
+}
+
+func (w desktopWindow) Size() (width, height int) {
+	width, height = w.WindowRuntime.Size()
+	if screen, ok := w.on(); ok && w.physical && screen.Scale > 0 {
+		width = int(math.Round(float64(width) * screen.Scale))
+		height = int(math.Round(float64(height) * screen.Scale))
+	}
+	return width, height
+}
+
+// SetSize uses the scale of the screen the window is on now, so after a move
+// to another screen its scale applies
+func (w desktopWindow) SetSize(width, height int) {
+	if screen, ok := w.on(); ok && w.physical && screen.Scale > 0 {
+		width = int(math.Round(float64(width) / screen.Scale))
+		height = int(math.Round(float64(height) / screen.Scale))
+	}
+	w.WindowRuntime.SetSize(width, height)
+}
+
+// cocoaScreen is the visible frame of an NSScreen in Cocoa coordinates:
+// origin at the bottom left of the primary screen, y up
+type cocoaScreen struct {
+	X, Y, Width, Height float64
+	Scale               float64 // backingScaleFactor
+}
+
+// cocoaToDesktop converts NSScreen visible frames to desktop coordinates
+// (top left of the primary screen, y down). primaryHeight is the height of
+// the primary screen's full frame, which the flipped y is measured from.
+func cocoaToDesktop(primaryHeight float64, screens []cocoaScreen) []platformScreen {
+	result := make([]platformScreen, 0, len(screens))
+	for _, s := range screens {
+		result = append(result, platformScreen{
+			Work: Rect{
+				X:      int(math.Round(s.X)),
+				Y:      int(math.Round(primaryHeight - (s.Y + s.Height))),
+				Width:  int(math.Round(s.Width)),
+				Height: int(math.Round(s.Height)),
+			},
+			Scale: s.Scale,
+		})
+	}
+	return result
+}
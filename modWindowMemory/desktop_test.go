@@ -0,0 +1,176 @@
+package modWindowMemory
+
+import "testing"
+
+// wailsFake behaves like the Wails window: positions are set relative to the
+// work area of the screen the window is on, and with relative reads (macOS)
+// also read that way. With physical sizes (Windows) its sizes are logical
+// pixels of that screen while the desktop is in physical pixels.
+type wailsFake struct {
+	*FakeRuntime
+	screens  []platformScreen
+	window   Rect // In desktop coordinates
+	relative bool
+	physical bool
+}
+
+// on returns the index of the screen the window is on
+func (f *wailsFake) on() int {
+	work := make([]Rect, len(f.screens))
+	for i, s := range f.screens {
+		work[i] = s.Work
+	}
+	screen, _ := screenOf(f.window, work)
+	for i, s := range f.screens {
+		if s.Work == screen {
+			return i
+		}
+	}
+	return -1
+}
+
+func (f *wailsFake) Position() (x, y int) {
+	if f.relative {
+		work := f.screens[f.on()].Work
+		return f.window.X - work.X, f.window.Y - work.Y
+	}
+	return f.window.X, f.window.Y
+}
+
+func (f *wailsFake) SetPosition(x, y int) {
+	work := f.screens[f.on()].Work
+	f.window.X, f.window.Y = work.X+x, work.Y+y
+}
+
+func (f *wailsFake) Size() (width, height int) {
+	if f.physical {
+		scale := f.screens[f.on()].Scale
+		return int(float64(f.window.Width) / scale), int(float64(f.window.Height) / scale)
+	}
+	return f.window.Width, f.window.Height
+}
+
+func (f *wailsFake) SetSize(width, height int) {
+	if f.physical {
+		scale := f.screens[f.on()].Scale
+		width, height = int(float64(width)*scale), int(float64(height)*scale)
+	}
+	f.window.Width, f.window.Height = width, height
+}
+
+func TestDesktopWindow(t *testing.T) {
+	// Windows: taskbar on the left of the primary monitor, a 200% monitor
+	// right of it; physical pixels
+	windows := []platformScreen{
+		{Work: Rect{X: 48, Y: 0, Width: 1872, Height: 1080}, Scale: 1},
+		{Work: Rect{X: 1920, Y: 0, Width: 3840, Height: 2100}, Scale: 2},
+	}
+	// macOS: a 1920x1080 screen above and left of a 1440x900 primary, menu
+	// bar on both; points
+	macos := cocoaToDesktop(900, []cocoaScreen{
+		{X: 0, Y: 0, Width: 1440, Height: 875, Scale: 2},
+		{X: -1920, Y: 900, Width: 1920, Height: 1055, Scale: 1},
+	})
+
+	tests := []struct {
+		name     string
+		screens  []platformScreen
+		relative bool
+		physical bool
+		start    Rect // Window before the move, desktop coordinates
+		target   Rect
+		wails    Rect // What Wails ends up with: absolute position, physical size
+	}{
+		{
+			name:     "windows: within the primary monitor",
+			screens:  windows,
+			physical: true,
+			start:    Rect{X: 100, Y: 100, Width: 800, Height: 600},
+			target:   Rect{X: 148, Y: 50, Width: 1000, Height: 700},
+			wails:    Rect{X: 148, Y: 50, Width: 1000, Height: 700},
+		},
+		{
+			name:     "windows: to the 200% monitor",
+			screens:  windows,
+			physical: true,
+			start:    Rect{X: 100, Y: 100, Width: 800, Height: 600},
+			target:   Rect{X: 2000, Y: 100, Width: 1600, Height: 1200},
+			wails:    Rect{X: 2000, Y: 100, Width: 1600, Height: 1200},
+		},
+		{
+			name:     "windows: back to the primary monitor",
+			screens:  windows,
+			physical: true,
+			start:    Rect{X: 2000, Y: 100, Width: 1600, Height: 1200},
+			target:   Rect{X: 300, Y: 200, Width: 800, Height: 600},
+			wails:    Rect{X: 300, Y: 200, Width: 800, Height: 600},
+		},
+		{
+			name:     "macos: within the primary screen",
+			screens:  macos,
+			relative: true,
+			start:    Rect{X: 100, Y: 100, Width: 800, Height: 600},
+			target:   Rect{X: 200, Y: 25, Width: 800, Height: 600},
+			wails:    Rect{X: 200, Y: 25, Width: 800, Height: 600},
+		},
+		{
+			name:     "macos: to the secondary screen",
+			screens:  macos,
+			relative: true,
+			start:    Rect{X: 100, Y: 100, Width: 800, Height: 600},
+			target:   Rect{X: -1800, Y: -1000, Width: 1000, Height: 700},
+			wails:    Rect{X: -1800, Y: -1000, Width: 1000, Height: 700},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &wailsFake{FakeRuntime: &FakeRuntime{}, screens: tt.screens, window: tt.start, relative: tt.relative, physical: tt.physical}
+			current := func() int { return fake.on() }
+			w := desktopWindow{
+				WindowRuntime: fake,
+				screens:       func() []platformScreen { return tt.screens },
+				relative:      tt.relative,
+				physical:      tt.physical,
+				current:       current,
+			}
+
+			if x, y := w.Position(); x != tt.start.X || y != tt.start.Y {
+				t.Errorf("start position (%d, %d), want (%d, %d)", x, y, tt.start.X, tt.start.Y)
+			}
+
+			// Same order as the manager: move first, then size
+			w.SetPosition(tt.target.X, tt.target.Y)
+			w.SetSize(tt.target.Width, tt.target.Height)
+			if fake.window != tt.wails {
+				t.Errorf("window at %v, want %v", fake.window, tt.wails)
+			}
+
+			x, y := w.Position()
+			width, height := w.Size()
+			if got := (Rect{X: x, Y: y, Width: width, Height: height}); got != tt.target {
+				t.Errorf("read back %v, want %v", got, tt.target)
+			}
+		})
+	}
+}
+
+func TestCocoaToDesktop(t *testing.T) {
+	got := cocoaToDesktop(900, []cocoaScreen{
+		{X: 0, Y: 0, Width: 1440, Height: 875, Scale: 2},        // Primary, menu bar at the top
+		{X: 1440, Y: -180, Width: 2560, Height: 1415, Scale: 1}, // Right, bottoms aligned lower
+		{X: -1920, Y: 900, Width: 1920, Height: 1055, Scale: 1}, // Above and left
+	})
+	want := []platformScreen{
+		{Work: Rect{X: 0, Y: 25, Width: 1440, Height: 875}, Scale: 2},
+		{Work: Rect{X: 1440, Y: -335, Width: 2560, Height: 1415}, Scale: 1},
+		{Work: Rect{X: -1920, Y: -1055, Width: 1920, Height: 1055}, Scale: 1},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("%d screens, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("screen %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
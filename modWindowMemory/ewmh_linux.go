@@ -0,0 +1,397 @@
+//go:build linux
+// +build linux
+
+package modWindowMemory
+
+import (
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+// EWMH window operations
+//
+// Window managers following the Extended Window Manager Hints publish the
+// managed windows in _NET_CLIENT_LIST, the owning process in _NET_WM_PID
+// (set by GTK on every window it creates) and
+// the window state in _NET_WM_STATE and its virtual desktop in
+// _NET_WM_DESKTOP. Changes are requested with client messages to the root
+// window, so the window manager can apply them to its frame:
+// _NET_MOVERESIZE_WINDOW, _NET_ACTIVE_WINDOW, _NET_WM_STATE, _NET_WM_DESKTOP.
+//
+// Without a window manager (e.g. a bare Xvfb) the top-level windows are used
+// and changed directly.
+
+// _NET_WM_STATE action adding a state
+const netWMStateAdd = 1
+
+// EWMH source indication: requests come from a pager or similar tool, which
+// window managers honour even for windows that aren't focused
+const ewmhSourcePager = 2
+
+// x11Shared is the connection used by the Linux backend, opened on first use
+var x11Shared struct {
+	mu     sync.Mutex
+	conn   *x11Conn
+	failed time.Time // Last failed connection attempt
+	logged bool      // Whether the failure was logged
+}
+
+// x11RetryDelay limits reconnection attempts when no X server is reachable
+const x11RetryDelay = 10 * time.Second
+
+// x11Display returns the shared X11 connection, connecting if needed
+func x11Display() (*x11Conn, error) {
+	x11Shared.mu.Lock()
+	defer x11Shared.mu.Unlock()
+
+	if x11Shared.conn != nil {
+		return x11Shared.conn, nil
+	}
+	if time.Since(x11Shared.failed) < x11RetryDelay {
+		return nil, errors.New("no X11 connection")
+	}
+	conn, err := dialX11("")
+	if err != nil {
+		x11Shared.failed = time.Now()
+		if !x11Shared.logged {
+			println("[WindowPos] Native X11 backend unavailable:", err.Error())
+			x11Shared.logged = true
+		}
+		return nil, err
+	}
+	x11Shared.conn = conn
+	return conn, nil
+}
+
+// withX11 runs fn on the shared connection. A broken connection is dropped,
+// so the next call reconnects; X11 error replies leave it open.
+func withX11(fn func(c *x11Conn) error) error {
+	c, err := x11Display()
+	if err != nil {
+		return err
+	}
+	err = fn(c)
+	var netErr net.Error
+	if errors.As(err, &netErr) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		x11Shared.mu.Lock()
+		if x11Shared.conn == c {
+			c.Close()
+			x11Shared.conn = nil
+		}
+		x11Shared.mu.Unlock()
+	}
+	return err
+}
+
+// errWindowNotFound is returned when no window matches a search
+var errWindowNotFound = errors.New("window not found")
+
+// clientWindows returns the windows managed by the window manager, or the
+// top-level windows if there is no EWMH window manager
+func (c *x11Conn) clientWindows() ([]uint32, error) {
+	clients, err := c.cardinals(c.root, "_NET_CLIENT_LIST")
+	if err != nil {
+		return nil, err
+	}
+	if len(clients) > 0 {
+		return clients, nil
+	}
+	return c.children(c.root)
+}
+
+// windowPID returns _NET_WM_PID, or 0 if the window doesn't set it
+func (c *x11Conn) windowPID(window uint32) int {
+	values, err := c.cardinals(window, "_NET_WM_PID")
+	if err != nil || len(values) == 0 {
+		return 0
+	}
+	return int(values[0])
+}
+
+// findWindowsByPID returns the windows owned by a process
+func (c *x11Conn) findWindowsByPID(pid int) ([]uint32, error) {
+	windows, err := c.clientWindows()
+	if err != nil {
+		return nil, err
+	}
+	var owned []uint32
+	for _, window := range windows {
+		if c.windowPID(window) == pid {
+			owned = append(owned, window)
+		}
+	}
+	if len(owned) == 0 {
+		return nil, errWindowNotFound
+	}
+	return owned, nil
+}
+
+// processWindow returns the main window of a process: the only window with
+// its _NET_WM_PID, or the first visible one. GTK also sets the PID on hidden
+// helper windows, which show up when there is no window manager.
+func (c *x11Conn) processWindow(pid int) (uint32, error) {
+	windows, err := c.findWindowsByPID(pid)
+	if err != nil {
+		return 0, err
+	}
+	if len(windows) == 1 {
+		return windows[0], nil
+	}
+	for _, window := range windows {
+		if visible, err := c.viewable(window); err == nil && visible {
+			return window, nil
+		}
+	}
+	return windows[0], nil
+}
+
+// supports reports whether the window manager announces an EWMH feature in _NET_SUPPORTED
+func (c *x11Conn) supports(feature string) bool {
+	atom, err := c.atom(feature)
+	if err != nil {
+		return false
+	}
+	supported, err := c.cardinals(c.root, "_NET_SUPPORTED")
+	if err != nil {
+		return false
+	}
+	for _, a := range supported {
+		if a == atom {
+			return true
+		}
+	}
+	return false
+}
+
+// moveResize sets the client area of a window; mask selects which of x, y,
+// width and height are changed (x11ConfigX, ...). Coordinates are the same as
+// those returned by geometry.
+func (c *x11Conn) moveResize(window uint32, mask uint16, x, y, width, height int) error {
+	if c.supports("_NET_MOVERESIZE_WINDOW") {
+		// Static gravity: x/y are the client area, not the window manager's frame
+		const staticGravity = 10
+		flags := uint32(staticGravity) | uint32(mask&0xf)<<8 | ewmhSourcePager<<12
+		return c.clientMessage(window, "_NET_MOVERESIZE_WINDOW", flags,
+			uint32(int32(x)), uint32(int32(y)), uint32(width), uint32(height))
+	}
+
+	var values []uint32
+	for bit, value := range []int{x, y, width, height} {
+		if mask&(1<<bit) != 0 {
+			values = append(values, uint32(int32(value)))
+		}
+	}
+	return c.configure(window, mask&0xf, values...)
+}
+
+// activate raises and focuses a window through _NET_ACTIVE_WINDOW, which also
+// un-minimises it and switches to its workspace
+func (c *x11Conn) activate(window uint32) error {
+	if c.supports("_NET_ACTIVE_WINDOW") {
+		return c.clientMessage(window, "_NET_ACTIVE_WINDOW", ewmhSourcePager, 0, 0)
+	}
+	// No window manager: map, raise and focus the window ourselves
+	const stackAbove = 0
+	if err := c.mapWindow(window); err != nil {
+		return err
+	}
+	if err := c.configure(window, x11ConfigStackMode, stackAbove); err != nil {
+		return err
+	}
+	return c.setInputFocus(window)
+}
+
+// windowState reads the maximised, fullscreen and hidden (minimised) flags of _NET_WM_STATE
+func (c *x11Conn) windowState(window uint32) (maximised, fullscreen, hidden bool, err error) {
+	states, err := c.cardinals(window, "_NET_WM_STATE")
+	if err != nil {
+		return false, false, false, err
+	}
+	has := func(name string) bool {
+		atom, err := c.atom(name)
+		if err != nil {
+			return false
+		}
+		for _, state := range states {
+			if state == atom {
+				return true
+			}
+		}
+		return false
+	}
+	maximised = has("_NET_WM_STATE_MAXIMIZED_VERT") && has("_NET_WM_STATE_MAXIMIZED_HORZ")
+	fullscreen = has("_NET_WM_STATE_FULLSCREEN")
+	hidden = has("_NET_WM_STATE_HIDDEN")
+	return maximised, fullscreen, hidden, nil
+}
+
+// changeState adds (netWMStateAdd) or removes up to two _NET_WM_STATE properties of a window
+func (c *x11Conn) changeState(window uint32, action uint32, first, second string) error {
+	atoms := []uint32{0, 0}
+	for i, name := range []string{first, second} {
+		if name == "" {
+			continue
+		}
+		atom, err := c.atom(name)
+		if err != nil {
+			return err
+		}
+		atoms[i] = atom
+	}
+	return c.clientMessage(window, "_NET_WM_STATE", action, atoms[0], atoms[1], ewmhSourcePager)
+}
+
+// desktop reads _NET_WM_DESKTOP of a window and _NET_NUMBER_OF_DESKTOPS
+func (c *x11Conn) desktop(window uint32) (desktop, count int, err error) {
+	const allDesktops = 0xFFFFFFFF // Sticky window
+	values, err := c.cardinals(window, "_NET_WM_DESKTOP")
+	if err != nil {
+		return 0, 0, err
+	}
+	counts, err := c.cardinals(c.root, "_NET_NUMBER_OF_DESKTOPS")
+	if err != nil {
+		return 0, 0, err
+	}
+	if len(values) == 0 || len(counts) == 0 || values[0] == allDesktops {
+		return 0, 0, errNoDesktop
+	}
+	return int(values[0]), int(counts[0]), nil
+}
+
+// setDesktop asks the window manager to move a window to a virtual desktop
+func (c *x11Conn) setDesktop(window uint32, desktop int) error {
+	if !c.supports("_NET_WM_DESKTOP") {
+		return errNoDesktop
+	}
+	return c.clientMessage(window, "_NET_WM_DESKTOP", uint32(desktop), ewmhSourcePager)
+}
+
+// x11Backend implements windowBackend with the built-in X11 client
+type x11Backend struct{}
+
+// processWindows caches the window found for a process
+var processWindows struct {
+	mu     sync.Mutex
+	pid    int
+	window uint32
+}
+
+// window returns the main window of a process. The cached window is checked
+// against _NET_WM_PID, since GTK may recreate it.
+func (x11Backend) window(c *x11Conn, pid int) (uint32, error) {
+	processWindows.mu.Lock()
+	defer processWindows.mu.Unlock()
+	if processWindows.pid == pid && processWindows.window != 0 && c.windowPID(processWindows.window) == pid {
+		return processWindows.window, nil
+	}
+
+	window, err := c.processWindow(pid)
+	if err != nil {
+		return 0, err
+	}
+	processWindows.pid, processWindows.window = pid, window
+	return window, nil
+}
+
+func (x11Backend) name() string {
+	return "x11"
+}
+
+func (x11Backend) physicalPixels() bool {
+	return true
+}
+
+func (b x11Backend) geometry(pid int) (r Rect, err error) {
+	err = withX11(func(c *x11Conn) error {
+		window, err := b.window(c, pid)
+		if err != nil {
+			return err
+		}
+		r.X, r.Y, r.Width, r.Height, err = c.geometry(window)
+		return err
+	})
+	return r, err
+}
+
+func (b x11Backend) moveResize(pid int, mask uint16, r Rect) error {
+	return withX11(func(c *x11Conn) error {
+		window, err := b.window(c, pid)
+		if err != nil {
+			return err
+		}
+		return c.moveResize(window, mask, r.X, r.Y, r.Width, r.Height)
+	})
+}
+
+func (b x11Backend) state(pid int) (maximised, fullscreen, hidden bool, err error) {
+	err = withX11(func(c *x11Conn) error {
+		window, err := b.window(c, pid)
+		if err != nil {
+			return err
+		}
+		maximised, fullscreen, hidden, err = c.windowState(window)
+		return err
+	})
+	return maximised, fullscreen, hidden, err
+}
+
+func (b x11Backend) setState(pid int, maximised, fullscreen bool) error {
+	return withX11(func(c *x11Conn) error {
+		window, err := b.window(c, pid)
+		if err != nil {
+			return err
+		}
+		if fullscreen {
+			return c.changeState(window, netWMStateAdd, "_NET_WM_STATE_FULLSCREEN", "")
+		}
+		if maximised {
+			return c.changeState(window, netWMStateAdd, "_NET_WM_STATE_MAXIMIZED_VERT", "_NET_WM_STATE_MAXIMIZED_HORZ")
+		}
+		return nil
+	})
+}
+
+func (b x11Backend) activate(pid int) error {
+	return withX11(func(c *x11Conn) error {
+		window, err := b.window(c, pid)
+		if err != nil {
+			return err
+		}
+		return c.activate(window)
+	})
+}
+
+func (b x11Backend) desktop(pid int) (desktop, count int, err error) {
+	err = withX11(func(c *x11Conn) error {
+		window, err := b.window(c, pid)
+		if err != nil {
+			return err
+		}
+		desktop, count, err = c.desktop(window)
+		return err
+	})
+	return desktop, count, err
+}
+
+func (b x11Backend) setDesktop(pid int, desktop int) error {
+	return withX11(func(c *x11Conn) error {
+		window, err := b.window(c, pid)
+		if err != nil {
+			return err
+		}
+		return c.setDesktop(window, desktop)
+	})
+}
+
+// screens returns the RandR monitors, primary first, or nil without RandR 1.5
+func (x11Backend) screens() (screens []Rect) {
+	withX11(func(c *x11Conn) error {
+		var err error
+		screens, err = c.monitors()
+		return err
+	})
+	return screens
+}
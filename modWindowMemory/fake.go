@@ -0,0 +1,120 @@
+package modWindowMemory
+
+import "sync"
+
+// FakeRuntime is an in-memory WindowRuntime for running the manager without a
+// window, e.g. in tests or tools. It behaves like a window that accepts every
+// change, except for the simulated decorations: PositionOffsetX/Y are added
+// to what Position reports, like the title bar and borders on Windows.
+//
+// The fields may be set before use; while the manager uses the fake, read
+// them through the methods or Snapshot. Safe for concurrent use.
+type FakeRuntime struct {
+	mu sync.Mutex
+
+	Window     WindowPosition // Geometry and state flags; Screen, Display etc. are unused
+	ScreenList []Rect         // Returned by Screens, primary first
+	Scales     []float64      // Scale factor of each screen in ScreenList, 1 if missing
+
+	PositionOffsetX int // Added to Window.X by Position
+	PositionOffsetY int // Added to Window.Y by Position
+
+	SetPositionCalls int // Number of SetPosition calls
+	SetSizeCalls     int // Number of SetSize calls
+}
+
+// Snapshot returns a copy of the current window geometry and state
+func (f *FakeRuntime) Snapshot() WindowPosition {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.Window
+}
+
+func (f *FakeRuntime) Position() (x, y int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.Window.X + f.PositionOffsetX, f.Window.Y + f.PositionOffsetY
+}
+
+func (f *FakeRuntime) SetPosition(x, y int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.Window.X, f.Window.Y = x, y
+	f.SetPositionCalls++
+}
+
+func (f *FakeRuntime) Size() (width, height int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.Window.Width, f.Window.Height
+}
+
+func (f *FakeRuntime) SetSize(width, height int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.Window.Width, f.Window.Height = width, height
+	f.SetSizeCalls++
+}
+
+func (f *FakeRuntime) Screens() []Rect {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Rect(nil), f.ScreenList...)
+}
+
+func (f *FakeRuntime) ScreenScale(screen Rect) float64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i, s := range f.ScreenList {
+		if s != screen {
+			continue
+		}
+		if i < len(f.Scales) {
+			return f.Scales[i]
+		}
+		return 1
+	}
+	return 0
+}
+
+func (f *FakeRuntime) IsMaximised() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.Window.Maximised
+}
+
+func (f *FakeRuntime) IsMinimised() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.Window.Minimised
+}
+
+func (f *FakeRuntime) IsFullscreen() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.Window.Fullscreen
+}
+
+func (f *FakeRuntime) Maximise() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.Window.Maximised = true
+}
+
+func (f *FakeRuntime) Unmaximise() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.Window.Maximised = false
+}
+
+func (f *FakeRuntime) Fullscreen() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.Window.Fullscreen = true
+}
+
+func (f *FakeRuntime) Unfullscreen() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.Window.Fullscreen = false
+}
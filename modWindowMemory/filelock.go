@@ -0,0 +1,134 @@
+package modWindowMemory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// File locks
+//
+// LockFile takes an advisory lock on a file shared by several processes:
+// shared for readers, exclusive for writers (flock on Linux and macOS,
+// LockFileEx on Windows, see filelock_*.go). The lock is first tried without
+// waiting. If another process holds it, the lock is contended and LockFile
+// blocks in the kernel until it is granted or ctx is done; without a deadline
+// it waits as long as it takes.
+//
+// A blocked lock call can't be interrupted on every platform, so the wait runs
+// in its own goroutine. If ctx ends first, LockFile returns a *LockError and
+// the lock is released as soon as the kernel grants it.
+
+// ErrLockContended matches the *LockError returned when another process held
+// the lock until ctx was done
+var ErrLockContended = errors.New("file is locked by another process")
+
+// LockError reports a lock that could not be taken in time
+type LockError struct {
+	Path      string        // Locked file
+	Exclusive bool          // Whether an exclusive lock was requested
+	Waited    time.Duration // How long LockFile waited
+	Err       error         // Why the wait ended, e.g. context.DeadlineExceeded
+}
+
+func (e *LockError) Error() string {
+	kind := "shared"
+	if e.Exclusive {
+		kind = "exclusive"
+	}
+	return fmt.Sprintf("%s lock on %s: %v after waiting %v (%v)", kind, e.Path, ErrLockContended, e.Waited.Round(time.Millisecond), e.Err)
+}
+
+// Unwrap makes errors.Is match both ErrLockContended and the context error
+func (e *LockError) Unwrap() []error {
+	return []error{ErrLockContended, e.Err}
+}
+
+// FileLock is a lock held on a file. Release it with Unlock.
+type FileLock struct {
+	file *os.File
+
+	Path      string        // Locked file
+	Exclusive bool          // Exclusive or shared lock
+	Waited    time.Duration // Time spent waiting for other processes, 0 if the lock was free
+}
+
+// Contended reports whether another process held the lock when it was requested
+func (l *FileLock) Contended() bool {
+	return l.Waited > 0
+}
+
+// Unlock releases the lock. Safe to call more than once and on a nil lock.
+func (l *FileLock) Unlock() error {
+	if l == nil || l.file == nil {
+		return nil
+	}
+	unlockFile(l.file)
+	err := l.file.Close()
+	l.file = nil
+	return err
+}
+
+// TryLockFile locks path like LockFile, but never waits: if another process
+// holds the lock, ErrLockContended is returned at once
+func TryLockFile(path string, exclusive bool) (*FileLock, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	locked, err := tryLockFile(file, exclusive)
+	if err != nil || !locked {
+		file.Close()
+		if err == nil {
+			err = ErrLockContended
+		}
+		return nil, err
+	}
+	return &FileLock{file: file, Path: path, Exclusive: exclusive}, nil
+}
+
+// LockFile locks path, creating it if needed: shared for reading, exclusive
+// for writing. ctx bounds the wait if another process holds the lock.
+func LockFile(ctx context.Context, path string, exclusive bool) (*FileLock, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	lock := &FileLock{file: file, Path: path, Exclusive: exclusive}
+
+	locked, err := tryLockFile(file, exclusive)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	if locked {
+		return lock, nil
+	}
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		done <- waitLockFile(file, exclusive)
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			file.Close()
+			return nil, err
+		}
+		lock.Waited = time.Since(start)
+		return lock, nil
+	case <-ctx.Done():
+		// Give the lock back once the pending call returns
+		go func() {
+			if <-done == nil {
+				unlockFile(file)
+			}
+			file.Close()
+		}()
+		return nil, &LockError{Path: path, Exclusive: exclusive, Waited: time.Since(start), Err: ctx.Err()}
+	}
+}
@@ -6,42 +6,39 @@ package modWindowMemory
 import (
 	"os"
 	"syscall"
-	"time"
 )
 
-// openWithLock opens a file with exclusive locking on Linux
-// Uses flock system call for advisory file locking
-func openWithLock(path string, flags int, exclusive bool) (*os.File, error) {
-	// Ensure file exists for read operations
-	if flags&os.O_RDONLY != 0 {
-		if _, err := os.Stat(path); os.IsNotExist(err) {
-			return nil, err
-		}
-	}
+// File locking on Linux uses the flock system call (advisory locks on the
+// open file, released when it is closed). See filelock.go.
 
-	file, err := os.OpenFile(path, flags, 0644)
-	if err != nil {
-		return nil, err
-	}
-
-	// Try to lock the file with timeout
-	lockType := syscall.LOCK_SH // Shared lock for reading
+// flockType returns the flock operation for a shared or exclusive lock
+func flockType(exclusive bool) int {
 	if exclusive {
-		lockType = syscall.LOCK_EX // Exclusive lock for writing
+		return syscall.LOCK_EX // Exclusive lock for writing
 	}
+	return syscall.LOCK_SH // Shared lock for reading
+}
 
-	for attempts := 0; attempts < 10; attempts++ {
-		err = syscall.Flock(int(file.Fd()), lockType|syscall.LOCK_NB)
-		if err == nil {
-			return file, nil
-		}
+// tryLockFile locks a file without waiting; false if another process holds the lock
+func tryLockFile(file *os.File, exclusive bool) (bool, error) {
+	err := syscall.Flock(int(file.Fd()), flockType(exclusive)|syscall.LOCK_NB)
+	if err == syscall.EWOULDBLOCK {
+		return false, nil
+	}
+	return err == nil, err
+}
 
-		// If lock failed, wait and retry
-		if attempts < 9 {
-			time.Sleep(time.Millisecond * 20)
+// waitLockFile locks a file, blocking until the lock is granted
+func waitLockFile(file *os.File, exclusive bool) error {
+	for {
+		err := syscall.Flock(int(file.Fd()), flockType(exclusive))
+		if err != syscall.EINTR {
+			return err
 		}
 	}
+}
 
-	file.Close()
-	return nil, err
+// unlockFile releases a lock taken by tryLockFile or waitLockFile
+func unlockFile(file *os.File) {
+	syscall.Flock(int(file.Fd()), syscall.LOCK_UN)
 }
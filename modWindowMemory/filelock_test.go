@@ -0,0 +1,165 @@
+package modWindowMemory
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// Locks on separate opens of a file exclude each other within one process as
+// well, so the tests don't need a second process
+
+func TestLockFileContention(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "windows.json.lock")
+
+	var holders, overlaps, contended atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			lock, err := LockFile(context.Background(), path, true)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if holders.Add(1) > 1 {
+				overlaps.Add(1)
+			}
+			if lock.Contended() {
+				contended.Add(1)
+			}
+			time.Sleep(50 * time.Millisecond)
+			holders.Add(-1)
+			lock.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	if overlaps.Load() != 0 {
+		t.Error("both goroutines held the exclusive lock at once")
+	}
+	if contended.Load() != 1 {
+		t.Errorf("%d locks were contended, want 1", contended.Load())
+	}
+}
+
+func TestLockFileDeadline(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "windows.json.lock")
+	held, err := LockFile(context.Background(), path, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	lock, err := LockFile(ctx, path, false)
+	if lock != nil || !errors.Is(err, ErrLockContended) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("LockFile() = %v, %v; want ErrLockContended and DeadlineExceeded", lock, err)
+	}
+	var lockErr *LockError
+	if !errors.As(err, &lockErr) || lockErr.Exclusive || lockErr.Waited < 50*time.Millisecond {
+		t.Errorf("error %+v, want a shared *LockError after the deadline", lockErr)
+	}
+	if time.Since(start) > time.Second {
+		t.Errorf("LockFile returned after %v, long after the deadline", time.Since(start))
+	}
+
+	// The abandoned wait gives the lock back once it is granted
+	held.Unlock()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		lock, err := TryLockFile(path, true)
+		if err == nil {
+			lock.Unlock()
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("lock never released after the timed out wait: %v", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestLockFileUpgrade(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "windows.json.lock")
+	ctx := context.Background()
+
+	// Readers share the lock, a writer has to wait for all of them
+	first, err := LockFile(ctx, path, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := TryLockFile(path, false)
+	if err != nil {
+		t.Fatalf("second shared lock: %v", err)
+	}
+	if _, err := TryLockFile(path, true); !errors.Is(err, ErrLockContended) {
+		t.Fatalf("exclusive lock while shared ones are held: %v", err)
+	}
+
+	// Load's upgrade: give up the shared lock, then wait for the exclusive one
+	first.Unlock()
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		second.Unlock()
+	}()
+	exclusive, err := LockFile(ctx, path, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer exclusive.Unlock()
+	if !exclusive.Contended() {
+		t.Error("exclusive lock granted while another reader held the shared lock")
+	}
+	if _, err := TryLockFile(path, false); !errors.Is(err, ErrLockContended) {
+		t.Errorf("shared lock while the exclusive one is held: %v", err)
+	}
+}
+
+// TestLoadRereadsBeforeRepair checks that Load repairs a damaged file only
+// after reading it again under the exclusive lock: another instance may have
+// repaired it while Load waited
+func TestLoadRereadsBeforeRepair(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "windows.json")
+	writeTestFile(t, path, "{")
+	writeTestFile(t, backupPath(path, 0), `{"version": 2, "windows": {"old": {"x": 1, "y": 1, "width": 800, "height": 600}}}`)
+
+	// Another instance reads too and keeps Load from upgrading its lock
+	other, err := LockFile(context.Background(), lockPath(path), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan error, 1)
+	wpm := NewWindowPositionManager()
+	go func() { done <- wpm.Load(path) }()
+
+	// Load has read the damaged file by now and waits for the exclusive
+	// lock; the other instance writes a healthy file meanwhile
+	time.Sleep(100 * time.Millisecond)
+	writeTestFile(t, path, `{"version": 2, "windows": {"new": {"x": 2, "y": 2, "width": 800, "height": 600}}}`)
+	other.Unlock()
+
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if wpm.Recovery() != nil {
+		t.Errorf("healthy file recovered anyway: %v", wpm.Recovery())
+	}
+	if wpm.GetPosition("new") == nil || wpm.GetPosition("old") != nil {
+		t.Error("positions not taken from the file read under the exclusive lock")
+	}
+	if files := quarantined(t, path); len(files) != 0 {
+		t.Errorf("healthy file quarantined: %v", files)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Error(err)
+	}
+}
@@ -6,20 +6,25 @@ package modWindowMemory
 import (
 	"os"
 	"syscall"
-	"time"
 	"unsafe"
 )
 
+// File locking on Windows uses the LockFileEx API on the first byte of the
+// file. See filelock.go.
+
 // Windows API constants for file locking
 const (
 	LOCKFILE_EXCLUSIVE_LOCK   = 0x00000002
 	LOCKFILE_FAIL_IMMEDIATELY = 0x00000001
+
+	errorLockViolation = syscall.Errno(33) // ERROR_LOCK_VIOLATION: held by another process
 )
 
-// Windows API function
+// Windows API functions
 var (
-	kernel32       = syscall.NewLazyDLL("kernel32.dll")
-	procLockFileEx = kernel32.NewProc("LockFileEx")
+	kernel32         = syscall.NewLazyDLL("kernel32.dll")
+	procLockFileEx   = kernel32.NewProc("LockFileEx")
+	procUnlockFileEx = kernel32.NewProc("UnlockFileEx")
 )
 
 // lockFileEx wraps the Windows LockFileEx API
@@ -38,44 +43,30 @@ func lockFileEx(handle syscall.Handle, flags, reserved, lockLow, lockHigh uint32
 	return nil
 }
 
-// openWithLock opens a file with exclusive locking on Windows
-// Uses Windows LockFileEx API for proper file locking
-func openWithLock(path string, flags int, exclusive bool) (*os.File, error) {
-	// Ensure file exists for read operations
-	if flags&os.O_RDONLY != 0 {
-		if _, err := os.Stat(path); os.IsNotExist(err) {
-			return nil, err
-		}
+// lockFlags returns the LockFileEx flags for a shared or exclusive lock
+func lockFlags(exclusive bool) uint32 {
+	if exclusive {
+		return LOCKFILE_EXCLUSIVE_LOCK
 	}
+	return 0
+}
 
-	file, err := os.OpenFile(path, flags, 0644)
-	if err != nil {
-		return nil, err
+// tryLockFile locks a file without waiting; false if another process holds the lock
+func tryLockFile(file *os.File, exclusive bool) (bool, error) {
+	err := lockFileEx(syscall.Handle(file.Fd()), lockFlags(exclusive)|LOCKFILE_FAIL_IMMEDIATELY, 0, 1, 0, &syscall.Overlapped{})
+	if err == errorLockViolation {
+		return false, nil
 	}
+	return err == nil, err
+}
 
-	// Try to lock the file with timeout
-	for attempts := 0; attempts < 10; attempts++ {
-		// Windows file locking via LockFileEx
-		overlapped := &syscall.Overlapped{}
-		lockFlags := uint32(LOCKFILE_FAIL_IMMEDIATELY)
-
-		if exclusive {
-			lockFlags |= LOCKFILE_EXCLUSIVE_LOCK
-		}
-
-		handle := syscall.Handle(file.Fd())
-		err = lockFileEx(handle, lockFlags, 0, 1, 0, overlapped)
-
-		if err == nil {
-			return file, nil
-		}
-
-		// If lock failed, wait and retry
-		if attempts < 9 {
-			time.Sleep(20 * time.Millisecond)
-		}
-	}
+// waitLockFile locks a file, blocking until the lock is granted (the handle
+// is synchronous, so LockFileEx only returns then)
+func waitLockFile(file *os.File, exclusive bool) error {
+	return lockFileEx(syscall.Handle(file.Fd()), lockFlags(exclusive), 0, 1, 0, &syscall.Overlapped{})
+}
 
-	file.Close()
-	return nil, err
+// unlockFile releases a lock taken by tryLockFile or waitLockFile
+func unlockFile(file *os.File) {
+	procUnlockFileEx.Call(uintptr(file.Fd()), 0, 1, 0, uintptr(unsafe.Pointer(&syscall.Overlapped{})))
 }
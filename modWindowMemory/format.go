@@ -0,0 +1,175 @@
+package modWindowMemory
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// File format versions
+//
+// Version 1 (SimpleAI 1.x) is a bare map keyed by window ID:
+//
+//	{"SimpleAI - ChatGPT": {"x": 100, "y": 200, "width": 1024, "height": 768}}
+//
+// Since version 2 the map is wrapped in an envelope, so the file can grow new
+// top-level fields and change its keys without breaking older data:
+//
+//	{"version": 2, "windows": {"SimpleAI - ChatGPT": {...}}}
+//
+// Load upgrades older files step by step through the migrations below and
+// writes the result back. The original file is kept as <file>.v<N> first, so a
+// user going back to an older release still has it.
+//
+// A file written by a newer release is loaded as far as it is understood, but
+// never written: Save returns ErrNewerFormat instead of dropping fields this
+// version doesn't know.
+
+// currentFormatVersion is the version written by Save
+const currentFormatVersion = 2
+
+// ErrNewerFormat is returned by Save if the file was written by a newer version
+var ErrNewerFormat = errors.New("positions file was written by a newer version and is read-only")
+
+// storedFile is the envelope of format version 2 and later
+type storedFile struct {
+	Version int                        `json:"version"`
+	Windows map[string]*WindowPosition `json:"windows"`
+}
+
+// migration upgrades the raw file content from one version to the next
+type migration struct {
+	from        int
+	description string
+	upgrade     func(data []byte) ([]byte, error)
+}
+
+// migrations lists the upgrade steps, one per version
+var migrations = []migration{
+	{from: 1, description: "wrap the window map in a versioned envelope", upgrade: migrateV1toV2},
+}
+
+// migrateV1toV2 wraps the bare window map of version 1
+func migrateV1toV2(data []byte) ([]byte, error) {
+	var windows map[string]json.RawMessage
+	if err := json.Unmarshal(data, &windows); err != nil {
+		return nil, err
+	}
+	if windows == nil {
+		windows = map[string]json.RawMessage{}
+	}
+	return json.Marshal(map[string]interface{}{
+		"version": 2,
+		"windows": windows,
+	})
+}
+
+// fileVersion detects the format version of a positions file. A top-level
+// "version" number means an envelope. Without one it is the version 1 map,
+// whose values are always objects - including a window called "version".
+// Any other "version" value is an error rather than a guess.
+func fileVersion(data []byte) (int, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return 0, err
+	}
+	raw, ok := fields["version"]
+	if !ok {
+		return 1, nil
+	}
+	if len(raw) > 0 && raw[0] == '{' {
+		return 1, nil // A window called "version" in a version 1 file
+	}
+	version, err := strconv.Atoi(string(raw))
+	if err != nil || version < 1 {
+		return 0, fmt.Errorf("invalid format version %s", raw)
+	}
+	return version, nil
+}
+
+// decodePositions parses a positions file of any version. Older versions are
+// migrated in memory; version is the one found in the file.
+// An empty file is a truncated write and counts as damaged.
+func decodePositions(data []byte) (positions map[string]*WindowPosition, version int, err error) {
+	if len(data) == 0 {
+		return nil, 0, errors.New("file is empty")
+	}
+	version, err = fileVersion(data)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	upgraded := data
+	for v := version; v < currentFormatVersion; v++ {
+		step, ok := findMigration(v)
+		if !ok {
+			return nil, version, fmt.Errorf("no migration from format version %d", v)
+		}
+		if upgraded, err = step.upgrade(upgraded); err != nil {
+			return nil, version, fmt.Errorf("migrating from format version %d: %w", v, err)
+		}
+	}
+
+	// Newer versions are read as far as this version understands them
+	var file storedFile
+	if err := json.Unmarshal(upgraded, &file); err != nil {
+		return nil, version, err
+	}
+	if file.Windows == nil {
+		file.Windows = make(map[string]*WindowPosition)
+	}
+	return file.Windows, version, nil
+}
+
+// encodePositions produces the content of a positions file in the current version
+func encodePositions(positions map[string]*WindowPosition) ([]byte, error) {
+	return json.Marshal(storedFile{Version: currentFormatVersion, Windows: positions})
+}
+
+// findMigration returns the upgrade step starting at a version
+func findMigration(from int) (migration, bool) {
+	for _, m := range migrations {
+		if m.from == from {
+			return m, true
+		}
+	}
+	return migration{}, false
+}
+
+// preMigrationPath returns where the original file of an older version is kept
+func preMigrationPath(storagePath string, version int) string {
+	return storagePath + ".v" + strconv.Itoa(version)
+}
+
+// upgradeStorage writes a migrated file in the current version, keeping the
+// original as <file>.v<N>. Called with the exclusive lock held.
+func upgradeStorage(storagePath string, original []byte, version int, positions map[string]*WindowPosition) error {
+	keep := preMigrationPath(storagePath, version)
+	if !fileExists(keep) {
+		if err := writeFileAtomic(keep, original); err != nil {
+			return err
+		}
+	}
+	data, err := encodePositions(positions)
+	if err != nil {
+		return err
+	}
+	for v := version; v < currentFormatVersion; v++ {
+		if step, ok := findMigration(v); ok {
+			println("[WindowPos] Migrating", storagePath, "from format version", v, "-", step.description)
+		}
+	}
+	return writeFileAtomic(storagePath, data)
+}
+
+// readVersion returns the format version of the file on disk, 0 if unknown
+func readVersion(storagePath string) int {
+	data, err := os.ReadFile(storagePath)
+	if err != nil {
+		return 0
+	}
+	version, _ := fileVersion(data)
+	return version
+}
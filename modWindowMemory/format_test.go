@@ -0,0 +1,106 @@
+package modWindowMemory
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    int
+		wantErr bool
+	}{
+		{name: "version 1 map", data: `{"SimpleAI - ChatGPT": {"x": 1, "y": 2, "width": 800, "height": 600}}`, want: 1},
+		{name: "empty version 1 map", data: `{}`, want: 1},
+		{name: "version 1 window called version", data: `{"version": {"x": 1, "y": 2, "width": 800, "height": 600}}`, want: 1},
+		{name: "version 2", data: `{"version": 2, "windows": {}}`, want: 2},
+		{name: "version 3", data: `{"version": 3, "windows": {}, "future": true}`, want: 3},
+		{name: "version 0", data: `{"version": 0, "windows": {}}`, wantErr: true},
+		{name: "fractional version", data: `{"version": 2.5, "windows": {}}`, wantErr: true},
+		{name: "version string", data: `{"version": "2", "windows": {}}`, wantErr: true},
+		{name: "version null", data: `{"version": null}`, wantErr: true},
+		{name: "not an object", data: `[1, 2]`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := fileVersion([]byte(tt.data))
+			if (err != nil) != tt.wantErr || (!tt.wantErr && got != tt.want) {
+				t.Errorf("fileVersion() = %d, %v; want %d (error %v)", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMigrateV1(t *testing.T) {
+	const v1 = `{"SimpleAI - ChatGPT": {"x": 100, "y": 200, "width": 1024, "height": 768}, "version": {"x": 5, "y": 6, "width": 640, "height": 480}}`
+	path := filepath.Join(t.TempDir(), "windows.json")
+	writeTestFile(t, path, v1)
+
+	wpm := NewWindowPositionManager()
+	if err := wpm.Load(path); err != nil {
+		t.Fatal(err)
+	}
+	for id, wantX := range map[string]int{"SimpleAI - ChatGPT": 100, "version": 5} {
+		if pos := wpm.GetPosition(id); pos == nil || pos.X != wantX {
+			t.Errorf("position %q = %+v, want X %d", id, pos, wantX)
+		}
+	}
+
+	// Upgraded on disk, the original kept as .v1
+	if v := readVersion(path); v != currentFormatVersion {
+		t.Errorf("file has version %d after Load, want %d", v, currentFormatVersion)
+	}
+	if kept, err := os.ReadFile(preMigrationPath(path, 1)); err != nil || string(kept) != v1 {
+		t.Errorf(".v1 = %q (%v), want the original file", kept, err)
+	}
+
+	// Round trip through Save and Load
+	if err := wpm.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	again := NewWindowPositionManager()
+	if err := again.Load(path); err != nil {
+		t.Fatal(err)
+	}
+	if pos := again.GetPosition("version"); pos == nil || pos.Width != 640 {
+		t.Errorf("position \"version\" after the round trip = %+v", pos)
+	}
+
+	// An older release wrote version 1 again: the first original is kept
+	writeTestFile(t, path, `{"other": {"x": 1, "y": 1, "width": 800, "height": 600}}`)
+	if err := NewWindowPositionManager().Load(path); err != nil {
+		t.Fatal(err)
+	}
+	if kept, _ := os.ReadFile(preMigrationPath(path, 1)); string(kept) != v1 {
+		t.Errorf(".v1 overwritten with %q", kept)
+	}
+}
+
+func TestNewerFormat(t *testing.T) {
+	const v3 = `{"version": 3, "windows": {"a": {"x": 10, "y": 20, "width": 800, "height": 600}}, "profiles": {}}`
+	path := filepath.Join(t.TempDir(), "windows.json")
+	writeTestFile(t, path, v3)
+
+	wpm := NewWindowPositionManager()
+	if err := wpm.Load(path); err != nil {
+		t.Fatal(err)
+	}
+	if pos := wpm.GetPosition("a"); pos == nil || pos.X != 10 {
+		t.Errorf("position a = %+v, want it read from the newer file", pos)
+	}
+
+	wpm.SetPosition("a", 30, 40, 800, 600)
+	if err := wpm.Save(path); !errors.Is(err, ErrNewerFormat) {
+		t.Errorf("Save() = %v, want ErrNewerFormat", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != v3 {
+		t.Errorf("newer file changed to %q", data)
+	}
+	if fileExists(backupPath(path, 0)) {
+		t.Error("newer file rotated into a backup")
+	}
+}
@@ -0,0 +1,163 @@
+package modWindowMemory
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Multi-monitor geometry
+//
+// Screens are rectangles in desktop coordinates: the primary screen starts at
+// (0, 0), other screens have offsets (negative left of/above the primary).
+// A saved position remembers the screen the window was on, so it can be put
+// back on that screen as long as it is connected:
+//
+//  1. The saved screen is still present: keep the window on it
+//  2. The saved screen is gone (or unknown, older windows.json): use the screen
+//     the window overlaps most, or the nearest screen if it overlaps none
+//  3. Shrink and move the window until it fits on that screen
+//
+// Positions are also kept per display configuration (see displayFingerprint),
+// so a laptop remembers one layout when docked and another one on the go.
+//
+// The list of screens comes from the window runtime (WindowRuntime.Screens,
+// runtime.go), which asks the platform first (platformScreens in
+// geometry_*.go). Where the platform can't report offsets, the Wails screens
+// are assumed to be side by side, primary first. On Windows and macOS the
+// window geometry is converted to the same coordinates (desktop.go).
+
+// Rect is a rectangle in desktop coordinates
+type Rect struct {
+	X      int `json:"x"`
+	Y      int `json:"y"`
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
+
+// bounds returns the rectangle covered by a window position
+func (pos WindowPosition) bounds() Rect {
+	return Rect{X: pos.X, Y: pos.Y, Width: pos.Width, Height: pos.Height}
+}
+
+// overlap returns the area shared by two rectangles
+func (r Rect) overlap(o Rect) int {
+	width := min(r.X+r.Width, o.X+o.Width) - max(r.X, o.X)
+	height := min(r.Y+r.Height, o.Y+o.Height) - max(r.Y, o.Y)
+	if width <= 0 || height <= 0 {
+		return 0
+	}
+	return width * height
+}
+
+// distanceSq returns the squared distance from the center of r to the closest
+// point of o (0 if the center lies inside o)
+func (r Rect) distanceSq(o Rect) int {
+	cx := r.X + r.Width/2
+	cy := r.Y + r.Height/2
+	dx := max(o.X-cx, 0, cx-(o.X+o.Width-1))
+	dy := max(o.Y-cy, 0, cy-(o.Y+o.Height-1))
+	return dx*dx + dy*dy
+}
+
+// screenOf returns the screen a window is on: the one it overlaps most, or the
+// nearest one if it is entirely off-screen. ok is false without screens.
+func screenOf(window Rect, screens []Rect) (screen Rect, ok bool) {
+	if len(screens) == 0 {
+		return Rect{}, false
+	}
+
+	best, bestOverlap := -1, 0
+	for i, s := range screens {
+		if area := window.overlap(s); area > bestOverlap {
+			best, bestOverlap = i, area
+		}
+	}
+	if best >= 0 {
+		return screens[best], true
+	}
+
+	nearest := 0
+	for i, s := range screens {
+		if window.distanceSq(s) < window.distanceSq(screens[nearest]) {
+			nearest = i
+		}
+	}
+	return screens[nearest], true
+}
+
+// targetScreen picks the screen a saved window should be restored on
+func targetScreen(pos WindowPosition, screens []Rect) (Rect, bool) {
+	if pos.Screen != nil {
+		for _, s := range screens {
+			if s == *pos.Screen {
+				return s, true // Saved screen is still connected
+			}
+		}
+	}
+	return screenOf(pos.bounds(), screens)
+}
+
+// fitToScreen shrinks and moves a window until it lies completely on screen
+func fitToScreen(window, screen Rect) Rect {
+	window.Width = min(window.Width, screen.Width)
+	window.Height = min(window.Height, screen.Height)
+	window.X = max(screen.X, min(window.X, screen.X+screen.Width-window.Width))
+	window.Y = max(screen.Y, min(window.Y, screen.Y+screen.Height-window.Height))
+	return window
+}
+
+// placeOnScreens returns where a saved window should be shown on the current
+// screens. The position is returned unchanged if no screens are known.
+func placeOnScreens(pos WindowPosition, screens []Rect) WindowPosition {
+	screen, ok := targetScreen(pos, screens)
+	if !ok {
+		println("[WindowPos] Warning: Could not get screen dimensions, skipping bounds validation")
+		return pos
+	}
+
+	fitted := fitToScreen(pos.bounds(), screen)
+	if fitted != pos.bounds() {
+		println("[WindowPos] Position/Size corrected from (", pos.X, ",", pos.Y, ",", pos.Width, "x", pos.Height, ") to (", fitted.X, ",", fitted.Y, ",", fitted.Width, "x", fitted.Height, ") on screen at (", screen.X, ",", screen.Y, ")")
+	}
+	pos.X, pos.Y, pos.Width, pos.Height = fitted.X, fitted.Y, fitted.Width, fitted.Height
+	pos.Screen = &screen
+	return pos
+}
+
+// withScreen records the screen a window is currently on and the display
+// configuration it was measured in
+func withScreen(pos WindowPosition, screens []Rect) WindowPosition {
+	if screen, ok := screenOf(pos.bounds(), screens); ok {
+		pos.Screen = &screen
+	}
+	pos.Display = displayFingerprint(screens)
+	return pos
+}
+
+// displayFingerprint identifies a display configuration by screen count, sizes
+// and arrangement, e.g. "2:1920x1080+0+0,2560x1440+1920+0" (primary first).
+// Returns "" if no screens are known.
+func displayFingerprint(screens []Rect) string {
+	if len(screens) == 0 {
+		return ""
+	}
+	parts := make([]string, 0, len(screens))
+	for _, s := range screens {
+		parts = append(parts, strconv.Itoa(s.Width)+"x"+strconv.Itoa(s.Height)+"+"+strconv.Itoa(s.X)+"+"+strconv.Itoa(s.Y))
+	}
+	return strconv.Itoa(len(screens)) + ":" + strings.Join(parts, ",")
+}
+
+// forDisplays returns the position saved for the current display
+// configuration, or pos itself if the window was never saved in it
+func (pos WindowPosition) forDisplays(screens []Rect) WindowPosition {
+	return pos.forDisplay(displayFingerprint(screens))
+}
+
+// forDisplay returns the position saved for a display fingerprint, or pos itself
+func (pos WindowPosition) forDisplay(fingerprint string) WindowPosition {
+	if entry, ok := pos.Displays[fingerprint]; ok && entry != nil {
+		return *entry
+	}
+	return pos
+}
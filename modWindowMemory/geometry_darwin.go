@@ -0,0 +1,82 @@
+//go:build darwin && cgo
+// +build darwin,cgo
+
+package modWindowMemory
+
+/*
+#cgo CFLAGS: -x objective-c
+#cgo LDFLAGS: -framework AppKit
+#import <AppKit/AppKit.h>
+
+typedef struct {
+	double x, y, width, height;
+	double scale;
+} visibleFrame;
+
+// screenFrames fills frames with the visible frame of every screen in
+// [NSScreen screens] order (primary first) and returns the number of screens.
+// primaryHeight receives the height of the primary screen's full frame.
+static int screenFrames(visibleFrame *frames, int max, double *primaryHeight) {
+	NSArray<NSScreen *> *screens = [NSScreen screens];
+	int count = 0;
+	for (NSScreen *screen in screens) {
+		if (count == 0) {
+			*primaryHeight = screen.frame.size.height;
+		}
+		if (count >= max) {
+			break;
+		}
+		NSRect visible = [screen visibleFrame];
+		frames[count].x = visible.origin.x;
+		frames[count].y = visible.origin.y;
+		frames[count].width = visible.size.width;
+		frames[count].height = visible.size.height;
+		frames[count].scale = [screen backingScaleFactor];
+		count++;
+	}
+	return count;
+}
+*/
+import "C"
+
+import "github.com/wailsapp/wails/v2/pkg/runtime"
+
+// maxScreens is the most screens platformScreens reports
+const maxScreens = 16
+
+// platformScreens returns the visible frame (without menu bar and Dock) of
+// every NSScreen in points, primary first, converted to desktop coordinates
+func platformScreens() []platformScreen {
+	var frames [maxScreens]C.visibleFrame
+	var primaryHeight C.double
+	count := int(C.screenFrames(&frames[0], maxScreens, &primaryHeight))
+
+	screens := make([]cocoaScreen, 0, count)
+	for _, f := range frames[:count] {
+		screens = append(screens, cocoaScreen{
+			X: float64(f.x), Y: float64(f.y), Width: float64(f.width), Height: float64(f.height),
+			Scale: float64(f.scale),
+		})
+	}
+	return cocoaToDesktop(float64(primaryHeight), screens)
+}
+
+// platformWindow converts the Wails geometry, which is relative to the
+// visible frame of the window's screen, to desktop coordinates. The Wails
+// screens come in the same [NSScreen screens] order, so the one flagged as
+// current is the window's screen.
+func platformWindow(rt wailsRuntime) WindowRuntime {
+	current := func() int {
+		screens, err := runtime.ScreenGetAll(rt.ctx)
+		if err != nil {
+			return -1
+		}
+		for i, screen := range screens {
+			if screen.IsCurrent {
+				return i
+			}
+		}
+		return -1
+	}
+	return desktopWindow{WindowRuntime: rt, screens: platformScreens, relative: true, current: current}
+}
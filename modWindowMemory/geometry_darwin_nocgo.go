@@ -0,0 +1,16 @@
+//go:build darwin && !cgo
+// +build darwin,!cgo
+
+package modWindowMemory
+
+// Without cgo (e.g. cross-compiled tools; Wails itself needs cgo on macOS)
+// NSScreen can't be asked: the Wails screen list is used, which has no
+// offsets (screens are assumed side by side)
+
+func platformScreens() []platformScreen {
+	return nil
+}
+
+func platformWindow(rt wailsRuntime) WindowRuntime {
+	return rt
+}
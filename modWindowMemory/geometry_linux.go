@@ -0,0 +1,67 @@
+//go:build linux
+// +build linux
+
+package modWindowMemory
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// xrandrGeometry matches the "1920x1080+1920+0" part of an xrandr output line
+var xrandrGeometry = regexp.MustCompile(`\s(\d+)x(\d+)\+(-?\d+)\+(-?\d+)`)
+
+// platformScreens returns the outputs and their offsets from the window
+// backend (see backend_linux.go): RandR monitors on the X11 connection, the
+// compositor on Wayland, xrandr with the xdotool fallback.
+// Returns nil if none is available (the Wails screens are used then). The
+// scale is left to the Wails screens.
+func platformScreens() []platformScreen {
+	backend, err := linuxBackend()
+	if err != nil {
+		return nil
+	}
+	var screens []platformScreen
+	for _, screen := range backend.screens() {
+		screens = append(screens, platformScreen{Work: screen})
+	}
+	return screens
+}
+
+// platformWindow returns the Wails runtime unchanged: GTK positions are in
+// desktop coordinates already, and the window backend works in those too
+func platformWindow(rt wailsRuntime) WindowRuntime {
+	return rt
+}
+
+// parseXrandrScreens extracts active outputs from "xrandr --query", e.g.
+//
+//	HDMI-1 connected primary 1920x1080+0+0 (normal left inverted right) 527mm x 296mm
+//	DP-1 connected 2560x1440+1920+0 (normal left inverted right) 597mm x 336mm
+//
+// The primary output is returned first. Connected but disabled outputs have no
+// geometry and are skipped.
+func parseXrandrScreens(output string) []Rect {
+	var primary, others []Rect
+	for _, line := range strings.Split(output, "\n") {
+		if !strings.Contains(line, " connected") {
+			continue
+		}
+		m := xrandrGeometry.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		width, _ := strconv.Atoi(m[1])
+		height, _ := strconv.Atoi(m[2])
+		x, _ := strconv.Atoi(m[3])
+		y, _ := strconv.Atoi(m[4])
+		screen := Rect{X: x, Y: y, Width: width, Height: height}
+		if strings.Contains(line, " primary ") {
+			primary = append(primary, screen)
+		} else {
+			others = append(others, screen)
+		}
+	}
+	return append(primary, others...)
+}
@@ -0,0 +1,85 @@
+package modWindowMemory
+
+import "testing"
+
+func TestFitToScreen(t *testing.T) {
+	screen := Rect{X: 0, Y: 0, Width: 1920, Height: 1080}
+	tests := []struct {
+		name   string
+		window Rect
+		want   Rect
+	}{
+		{name: "on screen", window: Rect{X: 100, Y: 100, Width: 800, Height: 600}, want: Rect{X: 100, Y: 100, Width: 800, Height: 600}},
+		{name: "past the right edge", window: Rect{X: 1500, Y: 100, Width: 800, Height: 600}, want: Rect{X: 1120, Y: 100, Width: 800, Height: 600}},
+		{name: "past the bottom edge", window: Rect{X: 100, Y: 900, Width: 800, Height: 600}, want: Rect{X: 100, Y: 480, Width: 800, Height: 600}},
+		{name: "above and left", window: Rect{X: -300, Y: -50, Width: 800, Height: 600}, want: Rect{X: 0, Y: 0, Width: 800, Height: 600}},
+		{name: "larger than the screen", window: Rect{X: 200, Y: 200, Width: 2560, Height: 1440}, want: Rect{X: 0, Y: 0, Width: 1920, Height: 1080}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := fitToScreen(tt.window, screen); got != tt.want {
+				t.Errorf("fitToScreen(%v) = %v, want %v", tt.window, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPlaceOnScreens(t *testing.T) {
+	primary := Rect{X: 0, Y: 0, Width: 1920, Height: 1080}
+	left := Rect{X: -1280, Y: 0, Width: 1280, Height: 1024}
+	gone := Rect{X: 1920, Y: 0, Width: 2560, Height: 1440}
+
+	tests := []struct {
+		name    string
+		pos     WindowPosition
+		screens []Rect
+		want    Rect
+		screen  *Rect
+	}{
+		{
+			name:    "on the primary screen",
+			pos:     WindowPosition{X: 100, Y: 100, Width: 800, Height: 600},
+			screens: []Rect{primary, left},
+			want:    Rect{X: 100, Y: 100, Width: 800, Height: 600},
+			screen:  &primary,
+		},
+		{
+			name:    "saved screen left of the primary",
+			pos:     WindowPosition{X: -1200, Y: 50, Width: 800, Height: 600, Screen: &left},
+			screens: []Rect{primary, left},
+			want:    Rect{X: -1200, Y: 50, Width: 800, Height: 600},
+			screen:  &left,
+		},
+		{
+			name:    "straddling two screens, kept on the saved one",
+			pos:     WindowPosition{X: -300, Y: 50, Width: 800, Height: 600, Screen: &left},
+			screens: []Rect{primary, left},
+			want:    Rect{X: -800, Y: 50, Width: 800, Height: 600},
+			screen:  &left,
+		},
+		{
+			name:    "saved screen disconnected",
+			pos:     WindowPosition{X: 2500, Y: 700, Width: 1600, Height: 900, Screen: &gone},
+			screens: []Rect{primary, left},
+			want:    Rect{X: 320, Y: 180, Width: 1600, Height: 900},
+			screen:  &primary,
+		},
+		{
+			name:    "no screens known",
+			pos:     WindowPosition{X: 5000, Y: 5000, Width: 800, Height: 600},
+			screens: nil,
+			want:    Rect{X: 5000, Y: 5000, Width: 800, Height: 600},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := placeOnScreens(tt.pos, tt.screens)
+			if got.bounds() != tt.want {
+				t.Errorf("placeOnScreens() = %v, want %v", got.bounds(), tt.want)
+			}
+			if (got.Screen == nil) != (tt.screen == nil) || got.Screen != nil && *got.Screen != *tt.screen {
+				t.Errorf("placeOnScreens() screen = %v, want %v", got.Screen, tt.screen)
+			}
+		})
+	}
+}
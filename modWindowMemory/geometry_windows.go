@@ -0,0 +1,111 @@
+//go:build windows
+// +build windows
+
+package modWindowMemory
+
+import (
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+// Windows API for monitor enumeration
+var (
+	user32                  = syscall.NewLazyDLL("user32.dll")
+	procEnumDisplayMonitors = user32.NewProc("EnumDisplayMonitors")
+	procGetMonitorInfoW     = user32.NewProc("GetMonitorInfoW")
+	shcore                  = syscall.NewLazyDLL("shcore.dll")
+	procGetDpiForMonitor    = shcore.NewProc("GetDpiForMonitor") // Windows 8.1 and later
+)
+
+// MONITORINFOF_PRIMARY marks the primary monitor in monitorInfo.dwFlags
+const MONITORINFOF_PRIMARY = 0x00000001
+
+// MDT_EFFECTIVE_DPI is the DPI GetDpiForMonitor reports for scaling windows
+const MDT_EFFECTIVE_DPI = 0
+
+// defaultDPI is the DPI of a monitor at 100% scale
+const defaultDPI = 96
+
+// winRect is the Windows RECT structure
+type winRect struct {
+	Left, Top, Right, Bottom int32
+}
+
+// monitorInfo is the Windows MONITORINFO structure
+type monitorInfo struct {
+	cbSize    uint32
+	rcMonitor winRect
+	rcWork    winRect
+	dwFlags   uint32
+}
+
+// foundMonitor is a monitor reported by EnumDisplayMonitors
+type foundMonitor struct {
+	info monitorInfo
+	dpi  uint32 // Effective DPI, 0 if unknown
+}
+
+var (
+	// monitorsMu serializes enumerations, the callback writes to monitorsFound
+	monitorsMu    sync.Mutex
+	monitorsFound []foundMonitor
+	// enumMonitorCallback is created once: Windows callbacks are never freed
+	enumMonitorCallback = syscall.NewCallback(func(hMonitor, hdc, clip, data uintptr) uintptr {
+		monitor := foundMonitor{}
+		monitor.info.cbSize = uint32(unsafe.Sizeof(monitor.info))
+		if r1, _, _ := procGetMonitorInfoW.Call(hMonitor, uintptr(unsafe.Pointer(&monitor.info))); r1 == 0 {
+			return 1 // Continue enumeration
+		}
+		if procGetDpiForMonitor.Find() == nil {
+			var dpiX, dpiY uint32
+			hr, _, _ := procGetDpiForMonitor.Call(hMonitor, MDT_EFFECTIVE_DPI, uintptr(unsafe.Pointer(&dpiX)), uintptr(unsafe.Pointer(&dpiY)))
+			if hr == 0 { // S_OK
+				monitor.dpi = dpiX
+			}
+		}
+		monitorsFound = append(monitorsFound, monitor)
+		return 1 // Continue enumeration
+	})
+)
+
+// platformScreens returns the work area (without taskbar) of every monitor in
+// physical pixels with its scale, primary first. Windows (and Wails, which is
+// per-monitor DPI aware) place windows in physical pixels; only window sizes
+// are logical, see desktop.go.
+func platformScreens() []platformScreen {
+	monitorsMu.Lock()
+	defer monitorsMu.Unlock()
+
+	monitorsFound = nil
+	if r1, _, _ := procEnumDisplayMonitors.Call(0, 0, enumMonitorCallback, 0); r1 == 0 {
+		return nil
+	}
+
+	var primary, others []platformScreen
+	for _, monitor := range monitorsFound {
+		work := monitor.info.rcWork
+		screen := platformScreen{
+			Work: Rect{
+				X:      int(work.Left),
+				Y:      int(work.Top),
+				Width:  int(work.Right - work.Left),
+				Height: int(work.Bottom - work.Top),
+			},
+			Scale: float64(monitor.dpi) / defaultDPI,
+		}
+		if monitor.info.dwFlags&MONITORINFOF_PRIMARY != 0 {
+			primary = append(primary, screen)
+		} else {
+			others = append(others, screen)
+		}
+	}
+	return append(primary, others...)
+}
+
+// platformWindow converts the Wails geometry to physical desktop coordinates:
+// positions are set relative to the work area of the window's monitor and
+// sizes are scaled by its DPI
+func platformWindow(rt wailsRuntime) WindowRuntime {
+	return desktopWindow{WindowRuntime: rt, screens: platformScreens, physical: true}
+}
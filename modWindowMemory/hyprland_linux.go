@@ -0,0 +1,270 @@
+//go:build linux
+// +build linux
+
+package modWindowMemory
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Hyprland backend
+//
+// Hyprland answers one request per connection on
+// $XDG_RUNTIME_DIR/hypr/<instance>/.socket.sock (/tmp/hypr/<instance>/ before
+// 0.40). "j/" requests return JSON, dispatchers return "ok":
+//
+//	j/clients                                      -> [{"address": "0x...", "pid": 1234, "at": [x, y], ...}]
+//	j/monitors                                     -> [{"x": 0, "y": 0, "width": 2560, "scale": 1.25, ...}]
+//	dispatch movewindowpixel exact 100 200,address:0x...
+//
+// Like sway, only floating windows can be placed.
+
+// hyprlandBackend implements windowBackend over the Hyprland socket
+type hyprlandBackend struct {
+	socket string
+}
+
+// hyprlandClient is a window in the j/clients reply
+type hyprlandClient struct {
+	Address  string `json:"address"`
+	PID      int    `json:"pid"`
+	At       [2]int `json:"at"`
+	Size     [2]int `json:"size"`
+	Floating bool   `json:"floating"`
+	Hidden   bool   `json:"hidden"`
+	Mapped   bool   `json:"mapped"`
+	// Fullscreen is 0 (none), 1 (maximised) or 2 (fullscreen) since
+	// Hyprland 0.42; older versions send a bool plus fullscreenMode
+	Fullscreen     json.RawMessage `json:"fullscreen"`
+	FullscreenMode int             `json:"fullscreenMode"`
+	Workspace      struct {
+		Name string `json:"name"`
+	} `json:"workspace"`
+}
+
+// hyprlandSocket returns the request socket of a Hyprland instance
+func hyprlandSocket(signature string) string {
+	if runtimeDir := os.Getenv("XDG_RUNTIME_DIR"); runtimeDir != "" {
+		socket := filepath.Join(runtimeDir, "hypr", signature, ".socket.sock")
+		if fileExists(socket) {
+			return socket
+		}
+	}
+	return filepath.Join(os.TempDir(), "hypr", signature, ".socket.sock")
+}
+
+func (b *hyprlandBackend) name() string {
+	return "hyprland"
+}
+
+func (b *hyprlandBackend) physicalPixels() bool {
+	return false
+}
+
+// request sends one request and returns the whole reply
+func (b *hyprlandBackend) request(request string) ([]byte, error) {
+	conn, err := net.DialTimeout("unix", b.socket, ipcTimeout)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(ipcTimeout))
+
+	if _, err := conn.Write([]byte(request)); err != nil {
+		return nil, err
+	}
+	return io.ReadAll(conn)
+}
+
+// dispatch runs dispatchers, batched if there are several, and checks
+// that each one answered "ok"
+func (b *hyprlandBackend) dispatch(dispatchers ...string) error {
+	request := "dispatch " + dispatchers[0]
+	if len(dispatchers) > 1 {
+		request = "[[BATCH]]dispatch " + strings.Join(dispatchers, ";dispatch ")
+	}
+	reply, err := b.request(request)
+	if err != nil {
+		return err
+	}
+	for _, line := range strings.Fields(string(reply)) {
+		if line != "ok" {
+			return errors.New("hyprland: " + strings.TrimSpace(string(reply)))
+		}
+	}
+	return nil
+}
+
+// window finds the window of a process
+func (b *hyprlandBackend) window(pid int) (hyprlandClient, error) {
+	reply, err := b.request("j/clients")
+	if err != nil {
+		return hyprlandClient{}, err
+	}
+	var clients []hyprlandClient
+	if err := json.Unmarshal(reply, &clients); err != nil {
+		return hyprlandClient{}, err
+	}
+	for _, client := range clients {
+		if client.PID == pid && client.Mapped {
+			return client, nil
+		}
+	}
+	return hyprlandClient{}, errWindowNotFound
+}
+
+// fullscreenState decodes both forms of the fullscreen field
+func (client hyprlandClient) fullscreenState() (maximised, fullscreen bool) {
+	var mode int
+	if err := json.Unmarshal(client.Fullscreen, &mode); err == nil {
+		return mode == 1, mode >= 2
+	}
+	var on bool
+	if err := json.Unmarshal(client.Fullscreen, &on); err == nil && on {
+		return client.FullscreenMode == 1, client.FullscreenMode != 1
+	}
+	return false, false
+}
+
+func (b *hyprlandBackend) geometry(pid int) (Rect, error) {
+	client, err := b.window(pid)
+	if err != nil {
+		return Rect{}, err
+	}
+	return Rect{X: client.At[0], Y: client.At[1], Width: client.Size[0], Height: client.Size[1]}, nil
+}
+
+func (b *hyprlandBackend) moveResize(pid int, mask uint16, r Rect) error {
+	client, err := b.window(pid)
+	if err != nil {
+		return err
+	}
+	if !client.Floating {
+		return errTiled
+	}
+
+	var dispatchers []string
+	if mask&(x11ConfigWidth|x11ConfigHeight) != 0 {
+		width, height := client.Size[0], client.Size[1]
+		if mask&x11ConfigWidth != 0 {
+			width = r.Width
+		}
+		if mask&x11ConfigHeight != 0 {
+			height = r.Height
+		}
+		dispatchers = append(dispatchers, fmt.Sprintf("resizewindowpixel exact %d %d,address:%s", width, height, client.Address))
+	}
+	if mask&(x11ConfigX|x11ConfigY) != 0 {
+		x, y := client.At[0], client.At[1]
+		if mask&x11ConfigX != 0 {
+			x = r.X
+		}
+		if mask&x11ConfigY != 0 {
+			y = r.Y
+		}
+		dispatchers = append(dispatchers, fmt.Sprintf("movewindowpixel exact %d %d,address:%s", x, y, client.Address))
+	}
+	if len(dispatchers) == 0 {
+		return nil
+	}
+	return b.dispatch(dispatchers...)
+}
+
+// state reads the fullscreen mode; windows on a special workspace count as hidden
+func (b *hyprlandBackend) state(pid int) (maximised, fullscreen, hidden bool, err error) {
+	client, err := b.window(pid)
+	if err != nil {
+		return false, false, false, err
+	}
+	maximised, fullscreen = client.fullscreenState()
+	hidden = client.Hidden || strings.HasPrefix(client.Workspace.Name, "special")
+	return maximised, fullscreen, hidden, nil
+}
+
+// setState uses the fullscreen dispatcher, which toggles and acts on the
+// focused window, so the window is focused first and left alone if it already
+// is in the state
+func (b *hyprlandBackend) setState(pid int, maximised, fullscreen bool) error {
+	if !maximised && !fullscreen {
+		return nil
+	}
+	client, err := b.window(pid)
+	if err != nil {
+		return err
+	}
+	isMaximised, isFullscreen := client.fullscreenState()
+	mode := 1 // Maximised
+	if fullscreen {
+		if isFullscreen {
+			return nil
+		}
+		mode = 0
+	} else if isMaximised {
+		return nil
+	}
+	return b.dispatch("focuswindow address:"+client.Address, fmt.Sprintf("fullscreen %d", mode))
+}
+
+func (b *hyprlandBackend) activate(pid int) error {
+	client, err := b.window(pid)
+	if err != nil {
+		return err
+	}
+	return b.dispatch("focuswindow address:" + client.Address)
+}
+
+// desktop returns errNoDesktop: workspaces are named and created on demand,
+// not numbered EWMH desktops
+func (b *hyprlandBackend) desktop(pid int) (desktop, count int, err error) {
+	return 0, 0, errNoDesktop
+}
+
+func (b *hyprlandBackend) setDesktop(pid int, desktop int) error {
+	return errNoDesktop
+}
+
+// screens returns the enabled monitors in logical (scaled) coordinates,
+// ordered by orderOutputs
+func (b *hyprlandBackend) screens() []Rect {
+	reply, err := b.request("j/monitors")
+	if err != nil {
+		return nil
+	}
+	var monitors []struct {
+		X         int     `json:"x"`
+		Y         int     `json:"y"`
+		Width     int     `json:"width"`
+		Height    int     `json:"height"`
+		Scale     float64 `json:"scale"`
+		Transform int     `json:"transform"`
+		Disabled  bool    `json:"disabled"`
+	}
+	if err := json.Unmarshal(reply, &monitors); err != nil {
+		return nil
+	}
+
+	var screens []Rect
+	for _, m := range monitors {
+		if m.Disabled {
+			continue
+		}
+		scale := m.Scale
+		if scale <= 0 {
+			scale = 1
+		}
+		width, height := int(float64(m.Width)/scale), int(float64(m.Height)/scale)
+		if m.Transform%2 == 1 {
+			width, height = height, width // Rotated by 90 or 270 degrees
+		}
+		screens = append(screens, Rect{X: m.X, Y: m.Y, Width: width, Height: height})
+	}
+	return orderOutputs(screens)
+}
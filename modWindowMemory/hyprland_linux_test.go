@@ -0,0 +1,121 @@
+//go:build linux
+// +build linux
+
+package modWindowMemory
+
+import (
+	"errors"
+	"net"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// fakeHyprland serves recorded j/clients and j/monitors replies, one request
+// per connection like Hyprland, and answers dispatchers with "ok"
+type fakeHyprland struct {
+	mu       sync.Mutex
+	replies  map[string][]byte
+	requests []string
+}
+
+func newFakeHyprland(t *testing.T) (*fakeHyprland, *hyprlandBackend) {
+	fake := &fakeHyprland{replies: map[string][]byte{
+		"j/clients":  readTestdata(t, "hyprland_clients.json"),
+		"j/monitors": readTestdata(t, "hyprland_monitors.json"),
+	}}
+	return fake, &hyprlandBackend{socket: serveUnix(t, fake.handle)}
+}
+
+func (f *fakeHyprland) handle(conn net.Conn) {
+	buf := make([]byte, 4096)
+	n, err := conn.Read(buf)
+	if err != nil {
+		return
+	}
+	request := string(buf[:n])
+
+	f.mu.Lock()
+	reply, ok := f.replies[request]
+	if !ok {
+		f.requests = append(f.requests, request)
+		reply = []byte("ok")
+	}
+	f.mu.Unlock()
+	conn.Write(reply)
+}
+
+func (f *fakeHyprland) sent() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.requests...)
+}
+
+func TestHyprlandScreens(t *testing.T) {
+	_, backend := newFakeHyprland(t)
+
+	// Focus is on DP-1, but the monitor at the origin comes first. DP-1 is
+	// 3840x2160 at scale 1.5; the disabled monitor is left out.
+	want := []Rect{
+		{X: 0, Y: 0, Width: 1920, Height: 1080},
+		{X: 1920, Y: 0, Width: 2560, Height: 1440},
+	}
+	if got := backend.screens(); !reflect.DeepEqual(got, want) {
+		t.Errorf("screens() = %v, want %v", got, want)
+	}
+}
+
+func TestHyprlandWindow(t *testing.T) {
+	_, backend := newFakeHyprland(t)
+
+	tests := []struct {
+		name                  string
+		pid                   int
+		geometry              Rect
+		maximised, fullscreen bool
+		hidden                bool
+		err                   error
+	}{
+		{name: "tiled", pid: 1001, geometry: Rect{X: 0, Y: 0, Width: 960, Height: 1080}},
+		{name: "maximised", pid: 2002, geometry: Rect{X: 2020, Y: 120, Width: 1024, Height: 768}, maximised: true},
+		{name: "special workspace, old fullscreen format", pid: 3003, geometry: Rect{X: 300, Y: 200, Width: 800, Height: 600}, hidden: true},
+		{name: "unknown process", pid: 4004, err: errWindowNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := backend.geometry(tt.pid)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("geometry() error = %v, want %v", err, tt.err)
+			}
+			if got != tt.geometry {
+				t.Errorf("geometry() = %v, want %v", got, tt.geometry)
+			}
+			if tt.err != nil {
+				return
+			}
+			maximised, fullscreen, hidden, err := backend.state(tt.pid)
+			if err != nil || maximised != tt.maximised || fullscreen != tt.fullscreen || hidden != tt.hidden {
+				t.Errorf("state() = %v, %v, %v, %v; want %v, %v, %v", maximised, fullscreen, hidden, err, tt.maximised, tt.fullscreen, tt.hidden)
+			}
+		})
+	}
+}
+
+func TestHyprlandMoveResize(t *testing.T) {
+	fake, backend := newFakeHyprland(t)
+
+	if err := backend.moveResize(1001, x11ConfigX|x11ConfigY, Rect{X: 10, Y: 10}); !errors.Is(err, errTiled) {
+		t.Errorf("moveResize() of a tiled window = %v, want errTiled", err)
+	}
+
+	mask := uint16(x11ConfigX | x11ConfigY | x11ConfigWidth | x11ConfigHeight)
+	if err := backend.moveResize(2002, mask, Rect{X: 2100, Y: 200, Width: 800, Height: 600}); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"[[BATCH]]dispatch resizewindowpixel exact 800 600,address:0x55d1c6b31a20;dispatch movewindowpixel exact 2100 200,address:0x55d1c6b31a20",
+	}
+	if got := fake.sent(); !reflect.DeepEqual(got, want) {
+		t.Errorf("requests = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,207 @@
+package modWindowMemory
+
+import (
+	"context"
+	"fmt"
+)
+
+// Placement of new windows
+//
+// A window without a saved position would open wherever the platform puts it,
+// which for several windows of one application is usually the same spot. With
+// a placement strategy set (SetPlacement), RestorePosition places such windows
+// itself, taking the other windows of the application into account:
+//
+//	cascade  Below and right of the newest other window, skipping spots that
+//	         are taken; wraps to the top of the screen at the bottom edge
+//	center   Centered on the screen of the newest other window, cascaded if
+//	         another window is already there
+//	tile     The spot on any screen that overlaps the other windows least:
+//	         screen corners and the space next to each window are tried
+//
+// Without other windows, every strategy centers on the primary screen. The
+// result is validated against the screens like a saved position.
+
+// PlacementStrategy selects where windows without a saved position open
+type PlacementStrategy string
+
+// Placement strategies. PlaceDefault leaves new windows to the platform.
+const (
+	PlaceDefault PlacementStrategy = ""
+	PlaceCascade PlacementStrategy = "cascade"
+	PlaceCenter  PlacementStrategy = "center"
+	PlaceTile    PlacementStrategy = "tile"
+)
+
+// cascadeStep is the offset between cascaded windows; windows whose top left
+// corners are closer than half of it count as being on the same spot
+const cascadeStep = 32
+
+// maxCascadeSteps bounds the search for a free cascade spot
+const maxCascadeSteps = 64
+
+// SiblingsFunc returns the geometry of the application's other windows,
+// oldest first. It is called once for every window that is placed.
+type SiblingsFunc func() []Rect
+
+// ParsePlacement checks a strategy name from a settings file
+func ParsePlacement(name string) (PlacementStrategy, error) {
+	switch strategy := PlacementStrategy(name); strategy {
+	case PlaceCascade, PlaceCenter, PlaceTile:
+		return strategy, nil
+	}
+	return PlaceDefault, fmt.Errorf("unknown placement strategy %q (use cascade, center or tile)", name)
+}
+
+// SetPlacement sets how windows without a saved position are placed.
+// siblings may be nil if there are no other windows to avoid.
+func (wpm *WindowPositionManager) SetPlacement(strategy PlacementStrategy, siblings SiblingsFunc) {
+	wpm.mu.Lock()
+	defer wpm.mu.Unlock()
+	wpm.placement = strategy
+	wpm.siblings = siblings
+}
+
+// newWindowPosition picks the position of a window that has no saved position.
+// ok is false if no strategy is set or the window size is unknown.
+func (wpm *WindowPositionManager) newWindowPosition(ctx context.Context) (pos WindowPosition, ok bool) {
+	wpm.mu.RLock()
+	strategy, siblings := wpm.placement, wpm.siblings
+	wpm.mu.RUnlock()
+	if strategy == PlaceDefault {
+		return WindowPosition{}, false
+	}
+
+	rt := wpm.window(ctx)
+	width, height := rt.Size()
+	if width == 0 || height == 0 {
+		return WindowPosition{}, false
+	}
+	var others []Rect
+	if siblings != nil {
+		others = siblings()
+	}
+
+	r := placeWindow(strategy, width, height, others, rt.Screens())
+	println("[WindowPos] Placing new window (", string(strategy), ") at X:", r.X, "Y:", r.Y, "W:", r.Width, "H:", r.Height, "avoiding", len(others), "other windows")
+	return WindowPosition{X: r.X, Y: r.Y, Width: r.Width, Height: r.Height}, true
+}
+
+// placeWindow returns the bounds for a new window of the given size
+func placeWindow(strategy PlacementStrategy, width, height int, siblings, screens []Rect) Rect {
+	window := Rect{Width: width, Height: height}
+	if len(screens) == 0 {
+		// Nothing to place on: only keep off the newest sibling
+		if len(siblings) > 0 {
+			newest := siblings[len(siblings)-1]
+			window.X, window.Y = newest.X+cascadeStep, newest.Y+cascadeStep
+		}
+		return window
+	}
+	if len(siblings) == 0 {
+		return centerOn(window, screens[0])
+	}
+
+	switch strategy {
+	case PlaceTile:
+		return tileWindow(window, siblings, screens)
+	case PlaceCenter:
+		screen, _ := screenOf(siblings[len(siblings)-1], screens)
+		return cascadeFrom(centerOn(window, screen), screen, siblings)
+	default:
+		newest := siblings[len(siblings)-1]
+		screen, _ := screenOf(newest, screens)
+		window.X, window.Y = newest.X+cascadeStep, newest.Y+cascadeStep
+		return cascadeFrom(window, screen, siblings)
+	}
+}
+
+// centerOn centers a window on a screen
+func centerOn(window, screen Rect) Rect {
+	window.X = screen.X + (screen.Width-window.Width)/2
+	window.Y = screen.Y + (screen.Height-window.Height)/2
+	return fitToScreen(window, screen)
+}
+
+// cascadeFrom moves a window down and right in steps until its spot is free.
+// At the bottom or right edge of the screen the cascade restarts at the top,
+// one step further right for each restart.
+func cascadeFrom(window, screen Rect, siblings []Rect) Rect {
+	start := window
+	restarts := 0
+	for i := 0; i < maxCascadeSteps; i++ {
+		if window.X+window.Width > screen.X+screen.Width || window.Y+window.Height > screen.Y+screen.Height {
+			restarts++
+			window.X = screen.X + restarts*cascadeStep
+			window.Y = screen.Y
+			if window.X+window.Width > screen.X+screen.Width {
+				break // Every column is taken
+			}
+		}
+		if !spotTaken(window, siblings) {
+			return window
+		}
+		window.X += cascadeStep
+		window.Y += cascadeStep
+	}
+	return fitToScreen(start, screen)
+}
+
+// spotTaken reports whether another window has its top left corner at
+// (almost) the same place
+func spotTaken(window Rect, siblings []Rect) bool {
+	for _, s := range siblings {
+		if abs(s.X-window.X) < cascadeStep/2 && abs(s.Y-window.Y) < cascadeStep/2 {
+			return true
+		}
+	}
+	return false
+}
+
+// tileWindow tries the screen corners and the space around every sibling and
+// returns the spot that overlaps the siblings least, preferring the primary
+// screen and the first candidates on ties
+func tileWindow(window Rect, siblings, screens []Rect) Rect {
+	var best Rect
+	bestOverlap := -1
+	for _, screen := range screens {
+		candidates := []Rect{
+			{X: screen.X, Y: screen.Y},
+			{X: screen.X + screen.Width - window.Width, Y: screen.Y},
+			{X: screen.X, Y: screen.Y + screen.Height - window.Height},
+			{X: screen.X + screen.Width - window.Width, Y: screen.Y + screen.Height - window.Height},
+		}
+		for _, s := range siblings {
+			candidates = append(candidates,
+				Rect{X: s.X + s.Width, Y: s.Y},       // Right of it
+				Rect{X: s.X, Y: s.Y + s.Height},      // Below
+				Rect{X: s.X - window.Width, Y: s.Y},  // Left of it
+				Rect{X: s.X, Y: s.Y - window.Height}, // Above
+			)
+		}
+
+		for _, c := range candidates {
+			c.Width, c.Height = window.Width, window.Height
+			c = fitToScreen(c, screen)
+			covered := 0
+			for _, s := range siblings {
+				covered += c.overlap(s)
+			}
+			if bestOverlap < 0 || covered < bestOverlap {
+				best, bestOverlap = c, covered
+			}
+			if covered == 0 {
+				return best
+			}
+		}
+	}
+	return best
+}
+
+// abs returns the absolute value of n
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
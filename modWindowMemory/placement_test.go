@@ -0,0 +1,111 @@
+package modWindowMemory
+
+import "testing"
+
+func TestPlaceWindow(t *testing.T) {
+	screen := Rect{Width: 1920, Height: 1080}
+	right := Rect{X: 1920, Width: 2560, Height: 1440}
+
+	tests := []struct {
+		name     string
+		strategy PlacementStrategy
+		width    int
+		siblings []Rect
+		screens  []Rect
+		want     Rect
+	}{
+		{
+			name:     "empty screen: centered",
+			strategy: PlaceCascade,
+			screens:  []Rect{screen},
+			want:     Rect{X: 560, Y: 240, Width: 800, Height: 600},
+		},
+		{
+			name:     "empty screen, tile: centered",
+			strategy: PlaceTile,
+			screens:  []Rect{screen, right},
+			want:     Rect{X: 560, Y: 240, Width: 800, Height: 600},
+		},
+		{
+			name:     "no screens: off the newest sibling",
+			strategy: PlaceCenter,
+			siblings: []Rect{{X: 100, Y: 100, Width: 800, Height: 600}},
+			want:     Rect{X: 132, Y: 132, Width: 800, Height: 600},
+		},
+		{
+			name:     "cascade below the newest sibling",
+			strategy: PlaceCascade,
+			siblings: []Rect{{X: 500, Y: 500, Width: 800, Height: 600}, {X: 100, Y: 100, Width: 800, Height: 600}},
+			screens:  []Rect{screen},
+			want:     Rect{X: 132, Y: 132, Width: 800, Height: 600},
+		},
+		{
+			name:     "sibling covering the default spot",
+			strategy: PlaceCascade,
+			siblings: []Rect{{X: 140, Y: 125, Width: 800, Height: 600}, {X: 100, Y: 100, Width: 800, Height: 600}},
+			screens:  []Rect{screen},
+			want:     Rect{X: 164, Y: 164, Width: 800, Height: 600},
+		},
+		{
+			name:     "cascade wraps to the top at the bottom edge",
+			strategy: PlaceCascade,
+			siblings: []Rect{{X: 1100, Y: 460, Width: 800, Height: 600}},
+			screens:  []Rect{screen},
+			want:     Rect{X: 32, Y: 0, Width: 800, Height: 600},
+		},
+		{
+			name:     "wrapped spot taken too",
+			strategy: PlaceCascade,
+			siblings: []Rect{{X: 32, Y: 0, Width: 800, Height: 600}, {X: 1100, Y: 460, Width: 800, Height: 600}},
+			screens:  []Rect{screen},
+			want:     Rect{X: 64, Y: 32, Width: 800, Height: 600},
+		},
+		{
+			name:     "full cascade wrap: every column taken",
+			strategy: PlaceCascade,
+			width:    1900,
+			siblings: []Rect{{X: 0, Y: 0, Width: 1900, Height: 600}},
+			screens:  []Rect{screen},
+			want:     Rect{X: 20, Y: 32, Width: 1900, Height: 600},
+		},
+		{
+			name:     "center: sibling covering the center",
+			strategy: PlaceCenter,
+			siblings: []Rect{{X: 560, Y: 240, Width: 800, Height: 600}},
+			screens:  []Rect{screen},
+			want:     Rect{X: 592, Y: 272, Width: 800, Height: 600},
+		},
+		{
+			name:     "center: on the screen of the newest sibling",
+			strategy: PlaceCenter,
+			siblings: []Rect{{X: 2000, Y: 100, Width: 800, Height: 600}},
+			screens:  []Rect{screen, right},
+			want:     Rect{X: 2800, Y: 420, Width: 800, Height: 600},
+		},
+		{
+			name:     "tile: beside a sibling covering the left half",
+			strategy: PlaceTile,
+			siblings: []Rect{{X: 0, Y: 0, Width: 960, Height: 1080}},
+			screens:  []Rect{screen},
+			want:     Rect{X: 1120, Y: 0, Width: 800, Height: 600},
+		},
+		{
+			name:     "tile: primary screen full, next screen",
+			strategy: PlaceTile,
+			siblings: []Rect{{X: 0, Y: 0, Width: 1920, Height: 1080}},
+			screens:  []Rect{screen, right},
+			want:     Rect{X: 1920, Y: 0, Width: 800, Height: 600},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			width := tt.width
+			if width == 0 {
+				width = 800
+			}
+			if got := placeWindow(tt.strategy, width, 600, tt.siblings, tt.screens); got != tt.want {
+				t.Errorf("placeWindow() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
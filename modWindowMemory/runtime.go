@@ -0,0 +1,207 @@
+package modWindowMemory
+
+import (
+	"context"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// Window runtime
+//
+// Everything the manager needs from the window toolkit goes through the
+// WindowRuntime interface instead of calling the Wails runtime directly:
+// geometry, screens and window state. By default the manager uses the Wails
+// runtime of the context passed to RestorePosition, SavePosition etc.
+// SetRuntime replaces it, e.g. with a FakeRuntime (fake.go) to run the
+// placement, offset compensation, drift correction and state logic without a
+// window:
+//
+//	fake := &FakeRuntime{ScreenList: []Rect{{Width: 1920, Height: 1080}}}
+//	wpm := NewWindowPositionManager()
+//	wpm.SetRuntime(fake)
+//	wpm.ApplyPosition(context.Background(), "test", WindowPosition{X: 5000, Y: 0, Width: 800, Height: 600})
+//	// fake.Snapshot() is now at (1120, 0), fully on the screen
+//
+// The Linux window backends (backend_linux.go) are separate: they talk to the
+// display server, not to the toolkit.
+
+// WindowRuntime reads and changes the application's own window
+type WindowRuntime interface {
+	Position() (x, y int)
+	SetPosition(x, y int)
+	Size() (width, height int)
+	SetSize(width, height int)
+
+	// Screens returns all screens in desktop coordinates, primary first.
+	// nil if unknown.
+	Screens() []Rect
+
+	// ScreenScale returns the scale factor (physical / logical pixels) of
+	// one of the screens returned by Screens; 0 if unknown.
+	ScreenScale(screen Rect) float64
+
+	IsMaximised() bool
+	IsMinimised() bool
+	IsFullscreen() bool
+	Maximise()
+	Unmaximise()
+	Fullscreen()
+	Unfullscreen()
+}
+
+// SetRuntime makes the manager use rt instead of the Wails runtime.
+// nil switches back to the Wails runtime.
+func (wpm *WindowPositionManager) SetRuntime(rt WindowRuntime) {
+	wpm.mu.Lock()
+	defer wpm.mu.Unlock()
+	wpm.runtime = rt
+}
+
+// window returns the runtime for an operation: the one set with SetRuntime,
+// or the Wails runtime of ctx
+func (wpm *WindowPositionManager) window(ctx context.Context) WindowRuntime {
+	wpm.mu.RLock()
+	rt := wpm.runtime
+	wpm.mu.RUnlock()
+	if rt != nil {
+		return rt
+	}
+	return WailsRuntime(ctx)
+}
+
+// wailsRuntime adapts the Wails runtime functions to WindowRuntime
+type wailsRuntime struct {
+	ctx context.Context
+}
+
+// WailsRuntime returns the WindowRuntime of a Wails application context, in
+// the desktop coordinates of the platform (see desktop.go)
+func WailsRuntime(ctx context.Context) WindowRuntime {
+	return platformWindow(wailsRuntime{ctx: ctx})
+}
+
+func (w wailsRuntime) Position() (x, y int) {
+	return runtime.WindowGetPosition(w.ctx)
+}
+
+func (w wailsRuntime) SetPosition(x, y int) {
+	runtime.WindowSetPosition(w.ctx, x, y)
+}
+
+func (w wailsRuntime) Size() (width, height int) {
+	return runtime.WindowGetSize(w.ctx)
+}
+
+func (w wailsRuntime) SetSize(width, height int) {
+	runtime.WindowSetSize(w.ctx, width, height)
+}
+
+// Screens prefers the platform's monitor list with offsets (geometry_*.go).
+// Wails doesn't report offsets, so its screens are placed side by side,
+// primary first.
+func (w wailsRuntime) Screens() []Rect {
+	if screens := platformScreens(); len(screens) > 0 {
+		rects := make([]Rect, 0, len(screens))
+		for _, screen := range screens {
+			rects = append(rects, screen.Work)
+		}
+		return rects
+	}
+
+	screens, err := runtime.ScreenGetAll(w.ctx)
+	if err != nil || len(screens) == 0 {
+		return nil
+	}
+
+	ordered := make([]runtime.Screen, 0, len(screens))
+	for _, screen := range screens {
+		if screen.IsPrimary {
+			ordered = append(ordered, screen)
+		}
+	}
+	for _, screen := range screens {
+		if !screen.IsPrimary {
+			ordered = append(ordered, screen)
+		}
+	}
+
+	rects := make([]Rect, 0, len(ordered))
+	x := 0
+	for _, screen := range ordered {
+		rects = append(rects, Rect{X: x, Y: 0, Width: screen.Width, Height: screen.Height})
+		x += screen.Width
+	}
+	return rects
+}
+
+// ScreenScale takes the scale the platform reports for the screen. Otherwise
+// it matches the screen to a Wails screen by its logical or physical size;
+// with a single screen, that one is used. The scale is its PhysicalSize
+// divided by its Size.
+func (w wailsRuntime) ScreenScale(screen Rect) float64 {
+	for _, s := range platformScreens() {
+		if s.Work == screen && s.Scale > 0 {
+			return s.Scale
+		}
+	}
+
+	screens, err := runtime.ScreenGetAll(w.ctx)
+	if err != nil {
+		return 0
+	}
+	for _, s := range screens {
+		if s.Size.Width <= 0 || s.PhysicalSize.Width <= 0 {
+			continue
+		}
+		logical := s.Size.Width == screen.Width && s.Size.Height == screen.Height
+		physical := s.PhysicalSize.Width == screen.Width && s.PhysicalSize.Height == screen.Height
+		if logical || physical || len(screens) == 1 {
+			return float64(s.PhysicalSize.Width) / float64(s.Size.Width)
+		}
+	}
+	return 0
+}
+
+func (w wailsRuntime) IsMaximised() bool {
+	return runtime.WindowIsMaximised(w.ctx)
+}
+
+func (w wailsRuntime) IsMinimised() bool {
+	return runtime.WindowIsMinimised(w.ctx)
+}
+
+func (w wailsRuntime) IsFullscreen() bool {
+	return runtime.WindowIsFullscreen(w.ctx)
+}
+
+func (w wailsRuntime) Maximise() {
+	runtime.WindowMaximise(w.ctx)
+}
+
+func (w wailsRuntime) Unmaximise() {
+	runtime.WindowUnmaximise(w.ctx)
+}
+
+func (w wailsRuntime) Fullscreen() {
+	runtime.WindowFullscreen(w.ctx)
+}
+
+func (w wailsRuntime) Unfullscreen() {
+	runtime.WindowUnfullscreen(w.ctx)
+}
+
+// isWailsRuntime reports whether rt is the Wails runtime, i.e. controls this
+// process's own window
+func isWailsRuntime(rt WindowRuntime) bool {
+	if w, ok := rt.(desktopWindow); ok {
+		rt = w.WindowRuntime
+	}
+	_, ok := rt.(wailsRuntime)
+	return ok
+}
+
+// Screens returns all screens in desktop coordinates, primary first, as seen
+// by the window runtime of ctx (nil if unknown)
+func (wpm *WindowPositionManager) Screens(ctx context.Context) []Rect {
+	return wpm.window(ctx).Screens()
+}
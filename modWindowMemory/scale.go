@@ -0,0 +1,73 @@
+package modWindowMemory
+
+import "math"
+
+// Scale factors (HiDPI)
+//
+// Window geometry is stored in the coordinates the platform places windows in.
+// Most are logical pixels: the Wails runtime on Linux, points on macOS, the
+// Wayland compositors. A logical size looks the same on every screen and is
+// restored unchanged. Physical pixels are used on Windows (desktop.go) and by
+// the X11 window backends; a window saved at 1024x768 physical pixels on a
+// 100% screen would come back at half its size on a 200% screen.
+//
+// Positions measured in physical pixels therefore record the scale factor of
+// the screen they were on (physical / logical pixels; 0 if unknown). On
+// restore through a physical backend, if the target screen has a different
+// scale, the size and the offset from the screen's top left corner are
+// converted by the ratio before the window is placed on the screen:
+//
+//	saved 1024x768 at scale 1, restored on a screen with scale 2 -> 2048x1536
+//
+// Positions without a scale (logical geometry, or saved before scales were
+// recorded) are restored unchanged.
+
+// roundScale rounds a scale factor to two decimals (1.25, 1.5, 1.75, ...)
+func roundScale(scale float64) float64 {
+	return math.Round(scale*100) / 100
+}
+
+// withScale records the scale factor of the screen a window is on, as
+// found by withScreen. Only for geometry in physical pixels.
+func withScale(pos WindowPosition, rt WindowRuntime) WindowPosition {
+	if pos.Screen != nil {
+		if scale := rt.ScreenScale(*pos.Screen); scale > 0 {
+			pos.Scale = roundScale(scale)
+		}
+	}
+	return pos
+}
+
+// rescale converts pos from the scale it was saved with to the scale of the
+// screen it is restored on. Returns pos unchanged if either scale is unknown
+// or both are the same.
+func rescale(pos WindowPosition, screen Rect, scale float64) WindowPosition {
+	scale = roundScale(scale)
+	if pos.Scale <= 0 || scale <= 0 || pos.Scale == scale {
+		return pos
+	}
+	ratio := scale / pos.Scale
+	origin := screen
+	if pos.Screen != nil {
+		origin = *pos.Screen
+	}
+
+	converted := pos
+	converted.X = screen.X + int(math.Round(float64(pos.X-origin.X)*ratio))
+	converted.Y = screen.Y + int(math.Round(float64(pos.Y-origin.Y)*ratio))
+	converted.Width = int(math.Round(float64(pos.Width) * ratio))
+	converted.Height = int(math.Round(float64(pos.Height) * ratio))
+	converted.Scale = scale
+	println("[WindowPos] Scale changed from", pos.Scale, "to", scale, "- size converted from", pos.Width, "x", pos.Height, "to", converted.Width, "x", converted.Height)
+	return converted
+}
+
+// placeScaled converts pos to the scale of the screen it is restored on and
+// then places it on the screens (see placeOnScreens). Only for geometry in
+// physical pixels; logical geometry goes to placeOnScreens directly.
+func placeScaled(pos WindowPosition, screens []Rect, rt WindowRuntime) WindowPosition {
+	if screen, ok := targetScreen(pos, screens); ok {
+		pos = rescale(pos, screen, rt.ScreenScale(screen))
+	}
+	return placeOnScreens(pos, screens)
+}
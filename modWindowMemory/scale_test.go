@@ -0,0 +1,110 @@
+package modWindowMemory
+
+import "testing"
+
+func TestRescale(t *testing.T) {
+	normal := Rect{X: 0, Y: 0, Width: 1920, Height: 1080}
+	hidpi := Rect{X: 1920, Y: 0, Width: 3840, Height: 2160}
+
+	tests := []struct {
+		name   string
+		pos    WindowPosition
+		screen Rect
+		scale  float64
+		want   Rect
+	}{
+		{
+			name:   "1.0 to 2.0",
+			pos:    WindowPosition{X: 100, Y: 50, Width: 1024, Height: 768, Screen: &normal, Scale: 1},
+			screen: hidpi,
+			scale:  2,
+			want:   Rect{X: 2120, Y: 100, Width: 2048, Height: 1536},
+		},
+		{
+			name:   "2.0 to 1.0",
+			pos:    WindowPosition{X: 2120, Y: 100, Width: 2048, Height: 1536, Screen: &hidpi, Scale: 2},
+			screen: normal,
+			scale:  1,
+			want:   Rect{X: 100, Y: 50, Width: 1024, Height: 768},
+		},
+		{
+			name:   "2.0 to 1.25",
+			pos:    WindowPosition{X: 1920, Y: 0, Width: 2048, Height: 1536, Screen: &hidpi, Scale: 2},
+			screen: normal,
+			scale:  1.25,
+			want:   Rect{X: 0, Y: 0, Width: 1280, Height: 960},
+		},
+		{
+			name:   "same scale",
+			pos:    WindowPosition{X: 100, Y: 50, Width: 1024, Height: 768, Screen: &normal, Scale: 2},
+			screen: hidpi,
+			scale:  2,
+			want:   Rect{X: 100, Y: 50, Width: 1024, Height: 768},
+		},
+		{
+			name:   "missing scale (legacy entry)",
+			pos:    WindowPosition{X: 100, Y: 50, Width: 1024, Height: 768},
+			screen: hidpi,
+			scale:  2,
+			want:   Rect{X: 100, Y: 50, Width: 1024, Height: 768},
+		},
+		{
+			name:   "unknown target scale",
+			pos:    WindowPosition{X: 100, Y: 50, Width: 1024, Height: 768, Screen: &normal, Scale: 1},
+			screen: hidpi,
+			want:   Rect{X: 100, Y: 50, Width: 1024, Height: 768},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := rescale(tt.pos, tt.screen, tt.scale)
+			if got.bounds() != tt.want {
+				t.Errorf("rescale() = %v, want %v", got.bounds(), tt.want)
+			}
+			if tt.want != tt.pos.bounds() && got.Scale != roundScale(tt.scale) {
+				t.Errorf("converted position has scale %v, want %v", got.Scale, tt.scale)
+			}
+		})
+	}
+}
+
+func TestPlaceScaled(t *testing.T) {
+	normal := Rect{X: 0, Y: 0, Width: 1920, Height: 1080}
+	hidpi := Rect{X: 1920, Y: 0, Width: 3840, Height: 2160}
+	fake := &FakeRuntime{ScreenList: []Rect{normal, hidpi}, Scales: []float64{1, 2}}
+
+	tests := []struct {
+		name string
+		pos  WindowPosition
+		want Rect
+	}{
+		{
+			name: "1.0 to 2.0",
+			pos:  WindowPosition{X: 2020, Y: 100, Width: 1024, Height: 768, Screen: &hidpi, Scale: 1},
+			want: Rect{X: 2120, Y: 200, Width: 2048, Height: 1536},
+		},
+		{
+			name: "2.0 to 1.0",
+			pos:  WindowPosition{X: 200, Y: 100, Width: 2048, Height: 1536, Screen: &normal, Scale: 2},
+			want: Rect{X: 100, Y: 50, Width: 1024, Height: 768},
+		},
+		{
+			name: "converted size is fitted on the screen",
+			pos:  WindowPosition{X: 1920, Y: 0, Width: 1920, Height: 1080, Screen: &hidpi, Scale: 0.5},
+			want: Rect{X: 1920, Y: 0, Width: 3840, Height: 2160},
+		},
+		{
+			name: "missing scale (legacy entry)",
+			pos:  WindowPosition{X: 2020, Y: 100, Width: 1024, Height: 768},
+			want: Rect{X: 2020, Y: 100, Width: 1024, Height: 768},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := placeScaled(tt.pos, fake.Screens(), fake)
+			if got.bounds() != tt.want {
+				t.Errorf("placeScaled() = %v, want %v", got.bounds(), tt.want)
+			}
+		})
+	}
+}
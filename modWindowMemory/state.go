@@ -0,0 +1,72 @@
+package modWindowMemory
+
+// Window state (maximised, fullscreen, minimised)
+//
+// While a window is maximised or fullscreen, its geometry is that of the
+// screen, and a minimised window has no usable geometry at all. Saving such a
+// window keeps the normal (restored) bounds from the previous save and only
+// updates the flags, so un-maximising after a restore returns to the old size.
+//
+// Restoring sets the normal bounds first and then re-applies maximised or
+// fullscreen. A window that was minimised reopens in the state it had before it
+// was minimised: it was just opened on purpose, so it should be visible.
+
+// readWindowState sets the state flags of pos from the window runtime
+func readWindowState(rt WindowRuntime, pos *WindowPosition) {
+	pos.Maximised = rt.IsMaximised()
+	pos.Minimised = rt.IsMinimised()
+	pos.Fullscreen = rt.IsFullscreen()
+}
+
+// hasWindowState reports whether the geometry of pos is not the normal bounds
+func (pos WindowPosition) hasWindowState() bool {
+	return pos.Maximised || pos.Fullscreen || pos.Minimised
+}
+
+// resetWindowState returns the window to the normal state, so new bounds can be applied
+func resetWindowState(rt WindowRuntime) {
+	if rt.IsFullscreen() {
+		rt.Unfullscreen()
+	}
+	if rt.IsMaximised() {
+		rt.Unmaximise()
+	}
+}
+
+// applyWindowState re-applies maximised or fullscreen after the bounds were set
+func applyWindowState(rt WindowRuntime, pos WindowPosition) {
+	switch {
+	case pos.Fullscreen:
+		rt.Fullscreen()
+	case pos.Maximised:
+		rt.Maximise()
+	}
+}
+
+// storeCurrentPosition stores a position read from the window. For maximised,
+// fullscreen or minimised windows the normal bounds of the previous save are
+// kept. Returns false if there is nothing usable to store (a minimised window
+// that was never saved before).
+func (wpm *WindowPositionManager) storeCurrentPosition(windowID string, pos WindowPosition) bool {
+	if pos.hasWindowState() {
+		wpm.mu.RLock()
+		saved := wpm.positions[windowID]
+		wpm.mu.RUnlock()
+
+		if saved != nil {
+			prev := saved.forDisplay(pos.Display)
+			pos.X, pos.Y, pos.Width, pos.Height = prev.X, prev.Y, prev.Width, prev.Height
+			pos.Screen, pos.Scale = prev.Screen, prev.Scale
+			if pos.Minimised {
+				// Remember what the window was before it was minimised
+				pos.Maximised = prev.Maximised
+				pos.Fullscreen = prev.Fullscreen
+			}
+		} else if pos.Minimised {
+			return false
+		}
+	}
+
+	wpm.SetWindowPosition(windowID, pos)
+	return true
+}
@@ -0,0 +1,85 @@
+package modWindowMemory
+
+import "testing"
+
+func TestStoreCurrentPosition(t *testing.T) {
+	screen := Rect{Width: 1920, Height: 1080}
+	saved := &WindowPosition{X: 100, Y: 100, Width: 800, Height: 600, Screen: &screen, Scale: 1.5}
+	savedMaximised := &WindowPosition{X: 200, Y: 150, Width: 1024, Height: 768, Maximised: true}
+
+	tests := []struct {
+		name   string
+		saved  *WindowPosition
+		pos    WindowPosition
+		stored bool
+		want   WindowPosition
+	}{
+		{
+			name:   "normal window",
+			saved:  saved,
+			pos:    WindowPosition{X: 300, Y: 200, Width: 640, Height: 480},
+			stored: true,
+			want:   WindowPosition{X: 300, Y: 200, Width: 640, Height: 480},
+		},
+		{
+			name:   "maximised keeps the normal bounds",
+			saved:  saved,
+			pos:    WindowPosition{X: 0, Y: 0, Width: 1920, Height: 1080, Maximised: true},
+			stored: true,
+			want:   WindowPosition{X: 100, Y: 100, Width: 800, Height: 600, Screen: &screen, Scale: 1.5, Maximised: true},
+		},
+		{
+			name:   "fullscreen keeps the normal bounds",
+			saved:  saved,
+			pos:    WindowPosition{X: 0, Y: 0, Width: 1920, Height: 1080, Fullscreen: true},
+			stored: true,
+			want:   WindowPosition{X: 100, Y: 100, Width: 800, Height: 600, Screen: &screen, Scale: 1.5, Fullscreen: true},
+		},
+		{
+			name:   "maximised, never saved",
+			pos:    WindowPosition{X: 0, Y: 0, Width: 1920, Height: 1080, Maximised: true},
+			stored: true,
+			want:   WindowPosition{X: 0, Y: 0, Width: 1920, Height: 1080, Maximised: true},
+		},
+		{
+			name:   "minimised keeps the state before",
+			saved:  savedMaximised,
+			pos:    WindowPosition{Minimised: true},
+			stored: true,
+			want:   WindowPosition{X: 200, Y: 150, Width: 1024, Height: 768, Minimised: true, Maximised: true},
+		},
+		{
+			name: "minimised, never saved",
+			pos:  WindowPosition{Minimised: true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wpm := NewWindowPositionManager()
+			if tt.saved != nil {
+				wpm.SetWindowPosition("test", *tt.saved)
+			}
+
+			if stored := wpm.storeCurrentPosition("test", tt.pos); stored != tt.stored {
+				t.Fatalf("storeCurrentPosition() = %v, want %v", stored, tt.stored)
+			}
+			got := wpm.GetPosition("test")
+			if !tt.stored {
+				if got != nil {
+					t.Errorf("position stored: %+v", *got)
+				}
+				return
+			}
+			if got == nil {
+				t.Fatal("no position stored")
+			}
+			if got.bounds() != tt.want.bounds() || got.Scale != tt.want.Scale ||
+				got.Maximised != tt.want.Maximised || got.Fullscreen != tt.want.Fullscreen || got.Minimised != tt.want.Minimised {
+				t.Errorf("stored %+v, want %+v", *got, tt.want)
+			}
+			if (got.Screen == nil) != (tt.want.Screen == nil) || got.Screen != nil && *got.Screen != *tt.want.Screen {
+				t.Errorf("stored screen %v, want %v", got.Screen, tt.want.Screen)
+			}
+		})
+	}
+}
@@ -0,0 +1,216 @@
+package modWindowMemory
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// Crash-safe storage
+//
+// The positions file is never written in place. Save writes <file>.tmp,
+// fsyncs it and renames it over <file>, so a crash leaves either the old or
+// the new content, never a truncated file. Before each write the current file
+// is rotated into up to backupGenerations copies:
+//
+//	windows.json.bak     previous version
+//	windows.json.bak.1   the one before
+//	windows.json.bak.2   ...
+//
+// Because the rename replaces the data file, locks are taken on a separate
+// <file>.lock that is never replaced.
+//
+// If Load finds a file it can't parse, the file is moved aside as
+// <file>.corrupt-<time> and the newest readable backup is loaded and written
+// back. What happened is available as a RecoveryReport.
+
+// backupGenerations is the number of .bak copies kept next to the positions file
+const backupGenerations = 3
+
+// RecoveryReport describes a damaged positions file found by Load
+type RecoveryReport struct {
+	Path         string    // Damaged file
+	Cause        error     // Why it couldn't be read
+	Quarantined  string    // Where the damaged file was moved, "" if it couldn't be moved
+	RestoredFrom string    // Backup the positions were recovered from, "" if none was usable
+	Time         time.Time // When the damage was found
+}
+
+// Recovered reports whether the positions could be restored from a backup
+func (r *RecoveryReport) Recovered() bool {
+	return r.RestoredFrom != ""
+}
+
+// Error describes the recovery; Load returns the report as error only if no
+// backup was usable
+func (r *RecoveryReport) Error() string {
+	msg := fmt.Sprintf("%s is damaged (%v)", filepath.Base(r.Path), r.Cause)
+	if r.Quarantined != "" {
+		msg += ", moved to " + filepath.Base(r.Quarantined)
+	}
+	if r.Recovered() {
+		return msg + ", positions restored from " + filepath.Base(r.RestoredFrom)
+	}
+	return msg + ", no usable backup - starting with empty positions"
+}
+
+// Unwrap returns the parse error
+func (r *RecoveryReport) Unwrap() error {
+	return r.Cause
+}
+
+// lockPath returns the lock file of a positions file
+func lockPath(storagePath string) string {
+	return storagePath + ".lock"
+}
+
+// backupPath returns the name of a backup generation (0 = newest)
+func backupPath(storagePath string, generation int) string {
+	if generation == 0 {
+		return storagePath + ".bak"
+	}
+	return storagePath + ".bak." + strconv.Itoa(generation)
+}
+
+// lockTimeout bounds the wait for the lock of a positions file in Load and
+// Save and when a measured position is saved
+const lockTimeout = 5 * time.Second
+
+// lockStorage locks a positions file: shared for reading, exclusive for writing.
+// Unlock the returned lock to release it.
+func lockStorage(ctx context.Context, storagePath string, exclusive bool) (*FileLock, error) {
+	lock, err := LockFile(ctx, lockPath(storagePath), exclusive)
+	if err == nil && lock.Contended() {
+		println("[WindowPos] Waited", lock.Waited.String(), "for the lock on", storagePath)
+	}
+	return lock, err
+}
+
+// readStorage reads a positions file with the exclusive lock held. A damaged
+// file is repaired from a backup (report is set then), an older format is
+// upgraded. positions is nil if the file doesn't exist.
+func readStorage(storagePath string) (positions map[string]*WindowPosition, version int, report *RecoveryReport, err error) {
+	data, err := os.ReadFile(storagePath)
+	if os.IsNotExist(err) {
+		return nil, 0, nil, nil
+	}
+	if err != nil {
+		return nil, 0, nil, err
+	}
+
+	positions, version, err = decodePositions(data)
+	if err != nil {
+		positions, report = recoverStorage(storagePath, err)
+		return positions, 0, report, nil
+	}
+	if version < currentFormatVersion {
+		// Older format (see format.go): upgrade, keeping the original
+		if err := upgradeStorage(storagePath, data, version, positions); err != nil {
+			println("[WindowPos] Could not upgrade", storagePath, "-", err.Error())
+		}
+	}
+	return positions, version, nil, nil
+}
+
+// writeFileAtomic writes data to a temp file, fsyncs it and renames it over path
+func writeFileAtomic(path string, data []byte) error {
+	tmp := path + ".tmp"
+	file, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	syncDir(filepath.Dir(path))
+	return nil
+}
+
+// syncDir flushes a directory entry after a rename. Not supported on Windows,
+// where the rename is durable on its own; errors are ignored.
+func syncDir(dir string) {
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+}
+
+// rotateBackups copies the current file into the newest backup generation.
+// Damaged files are not backed up, so they can't push out good copies.
+// Called with the exclusive lock held.
+func rotateBackups(storagePath string) {
+	data, err := os.ReadFile(storagePath)
+	if err != nil {
+		return
+	}
+	if _, _, err := decodePositions(data); err != nil {
+		return
+	}
+	for generation := backupGenerations - 1; generation > 0; generation-- {
+		os.Rename(backupPath(storagePath, generation-1), backupPath(storagePath, generation))
+	}
+	if err := writeFileAtomic(backupPath(storagePath, 0), data); err != nil {
+		println("[WindowPos] Could not write backup:", err.Error())
+	}
+}
+
+// recoverStorage quarantines a damaged positions file and loads the newest
+// readable backup. Called with the exclusive lock held.
+func recoverStorage(storagePath string, cause error) (map[string]*WindowPosition, *RecoveryReport) {
+	report := &RecoveryReport{Path: storagePath, Cause: cause, Time: time.Now()}
+
+	quarantine := storagePath + ".corrupt-" + report.Time.Format("20060102-150405")
+	for n := 2; fileExists(quarantine); n++ {
+		quarantine = storagePath + ".corrupt-" + report.Time.Format("20060102-150405") + "-" + strconv.Itoa(n)
+	}
+	if err := os.Rename(storagePath, quarantine); err == nil {
+		report.Quarantined = quarantine
+	}
+
+	positions := make(map[string]*WindowPosition)
+	for generation := 0; generation < backupGenerations; generation++ {
+		path := backupPath(storagePath, generation)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		restored, _, err := decodePositions(data)
+		if err != nil {
+			continue
+		}
+		positions = restored
+		report.RestoredFrom = path
+		// Put the recovered content back, so the next Load finds a healthy file
+		if err := writeFileAtomic(storagePath, data); err != nil {
+			println("[WindowPos] Could not write recovered positions:", err.Error())
+		}
+		break
+	}
+
+	println("[WindowPos]", report.Error())
+	return positions, report
+}
+
+// fileExists reports whether a file or directory exists at path
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
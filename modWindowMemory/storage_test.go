@@ -0,0 +1,167 @@
+package modWindowMemory
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeTestFile writes content to path
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// quarantined returns the damaged files moved aside next to path
+func quarantined(t *testing.T, path string) []string {
+	t.Helper()
+	files, err := filepath.Glob(path + ".corrupt-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+func TestRecoverStorage(t *testing.T) {
+	const good = `{"version": 2, "windows": {"a": {"x": 10, "y": 20, "width": 800, "height": 600}}}`
+	const older = `{"version": 2, "windows": {"b": {"x": 30, "y": 40, "width": 800, "height": 600}}}`
+
+	tests := []struct {
+		name     string
+		file     string
+		backups  []string // .bak, .bak.1, ...; "" for a missing generation
+		restored string   // Backup the positions come from, "" if none
+		want     string   // Window ID expected after the recovery
+	}{
+		{
+			name:     "truncated file, recovered from .bak",
+			file:     good[:40],
+			backups:  []string{good},
+			restored: ".bak",
+			want:     "a",
+		},
+		{
+			name:     "empty file",
+			file:     "",
+			backups:  []string{good},
+			restored: ".bak",
+			want:     "a",
+		},
+		{
+			name:     "newest backup damaged too",
+			file:     "{",
+			backups:  []string{"garbage", older},
+			restored: ".bak.1",
+			want:     "b",
+		},
+		{
+			name:     "missing generation skipped",
+			file:     "{",
+			backups:  []string{"", "", older},
+			restored: ".bak.2",
+			want:     "b",
+		},
+		{
+			name:    "all backups damaged",
+			file:    "{",
+			backups: []string{"", "[1, 2", "null null"},
+		},
+		{
+			name: "no backups",
+			file: `{"version": 2, "windows": `,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "windows.json")
+			writeTestFile(t, path, tt.file)
+			for generation, content := range tt.backups {
+				if content != "" {
+					writeTestFile(t, backupPath(path, generation), content)
+				}
+			}
+
+			wpm := NewWindowPositionManager()
+			err := wpm.Load(path)
+
+			report := wpm.Recovery()
+			if report == nil {
+				t.Fatalf("no recovery report (Load: %v)", err)
+			}
+			if len(quarantined(t, path)) != 1 || report.Quarantined == "" {
+				t.Errorf("damaged file not quarantined: %v, report %q", quarantined(t, path), report.Quarantined)
+			}
+
+			if tt.restored == "" {
+				var loadReport *RecoveryReport
+				if !errors.As(err, &loadReport) || loadReport.Recovered() {
+					t.Fatalf("Load() = %v, want an unrecovered *RecoveryReport", err)
+				}
+				if len(wpm.positions) != 0 {
+					t.Errorf("positions %v, want none", wpm.positions)
+				}
+				if fileExists(path) {
+					t.Error("a positions file was written without a usable backup")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Load() = %v, want the recovery to succeed", err)
+			}
+			if !strings.HasSuffix(report.RestoredFrom, tt.restored) {
+				t.Errorf("restored from %q, want %s", report.RestoredFrom, tt.restored)
+			}
+			if wpm.GetPosition(tt.want) == nil {
+				t.Errorf("position %s not recovered", tt.want)
+			}
+
+			// The recovered content is put back, the next Load finds a healthy file
+			again := NewWindowPositionManager()
+			if err := again.Load(path); err != nil || again.Recovery() != nil || again.GetPosition(tt.want) == nil {
+				t.Errorf("file not repaired: %v, %v", err, again.Recovery())
+			}
+		})
+	}
+}
+
+func TestRotateBackups(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "windows.json")
+	wpm := NewWindowPositionManager()
+
+	// Save x = 1 .. 5: the file holds 5, the backups 4, 3 and 2
+	for x := 1; x <= 5; x++ {
+		wpm.SetPosition("a", x, 0, 800, 600)
+		if err := wpm.Save(path); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	check := func(path string, wantX int) {
+		t.Helper()
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("%s: %v", filepath.Base(path), err)
+		}
+		positions, _, err := decodePositions(data)
+		if err != nil || positions["a"] == nil || positions["a"].X != wantX {
+			t.Errorf("%s holds %v (%v), want x = %d", filepath.Base(path), positions["a"], err, wantX)
+		}
+	}
+	check(path, 5)
+	for generation := 0; generation < backupGenerations; generation++ {
+		check(backupPath(path, generation), 4-generation)
+	}
+	if fileExists(backupPath(path, backupGenerations)) {
+		t.Errorf("more than %d backup generations kept", backupGenerations)
+	}
+
+	// A damaged file is not rotated into the backups
+	writeTestFile(t, path, "{")
+	rotateBackups(path)
+	check(backupPath(path, 0), 4)
+}
@@ -0,0 +1,285 @@
+//go:build linux
+// +build linux
+
+package modWindowMemory
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+// sway/i3 IPC backend
+//
+// sway speaks the i3 IPC protocol on the Unix socket in $SWAYSOCK. Every
+// message is the magic string "i3-ipc", the payload length and message type
+// (32-bit, native byte order) and the payload:
+//
+//	RUN_COMMAND  "[con_id=42] move absolute position 100 200" -> [{"success": true}]
+//	GET_OUTPUTS  -> [{"name": "DP-1", "active": true, "rect": {...}}, ...]
+//	GET_TREE     -> {"type": "root", "nodes": [...], "floating_nodes": [...]}
+//
+// Windows are found in the tree by their "pid". Tiled windows get their
+// geometry from the layout and can't be placed; floating windows can.
+
+// i3 IPC message types
+const (
+	swayRunCommand = 0
+	swayGetOutputs = 3
+	swayGetTree    = 4
+)
+
+// ipcTimeout bounds a round trip to a compositor socket
+const ipcTimeout = 2 * time.Second
+
+// swayMagic starts every i3 IPC message
+const swayMagic = "i3-ipc"
+
+// swayBackend implements windowBackend over the sway/i3 IPC socket
+type swayBackend struct {
+	socket string
+}
+
+// swayNode is a container in the GET_TREE reply
+type swayNode struct {
+	ID             int64      `json:"id"`
+	Type           string     `json:"type"`
+	Name           string     `json:"name"`
+	PID            int        `json:"pid"`
+	Rect           Rect       `json:"rect"`        // Container in desktop coordinates
+	WindowRect     Rect       `json:"window_rect"` // Client area, relative to Rect
+	FullscreenMode int        `json:"fullscreen_mode"`
+	Nodes          []swayNode `json:"nodes"`
+	FloatingNodes  []swayNode `json:"floating_nodes"`
+}
+
+// swayWindow is a window found in the tree
+type swayWindow struct {
+	node     swayNode
+	floating bool
+	hidden   bool // In the scratchpad
+}
+
+func (b *swayBackend) name() string {
+	return "sway"
+}
+
+func (b *swayBackend) physicalPixels() bool {
+	return false
+}
+
+// request sends one IPC message and returns the reply payload
+func (b *swayBackend) request(kind uint32, payload string) ([]byte, error) {
+	conn, err := net.DialTimeout("unix", b.socket, ipcTimeout)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(ipcTimeout))
+
+	var message bytes.Buffer
+	message.WriteString(swayMagic)
+	binary.Write(&message, binary.LittleEndian, uint32(len(payload)))
+	binary.Write(&message, binary.LittleEndian, kind)
+	message.WriteString(payload)
+	if _, err := conn.Write(message.Bytes()); err != nil {
+		return nil, err
+	}
+
+	header := make([]byte, len(swayMagic)+8)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return nil, err
+	}
+	if string(header[:len(swayMagic)]) != swayMagic {
+		return nil, errors.New("not an i3 IPC reply")
+	}
+	reply := make([]byte, binary.LittleEndian.Uint32(header[len(swayMagic):]))
+	if _, err := io.ReadFull(conn, reply); err != nil {
+		return nil, err
+	}
+	return reply, nil
+}
+
+// command runs sway commands and reports the first failure
+func (b *swayBackend) command(format string, args ...interface{}) error {
+	reply, err := b.request(swayRunCommand, fmt.Sprintf(format, args...))
+	if err != nil {
+		return err
+	}
+	var results []struct {
+		Success bool   `json:"success"`
+		Error   string `json:"error"`
+	}
+	if err := json.Unmarshal(reply, &results); err != nil {
+		return err
+	}
+	for _, result := range results {
+		if !result.Success {
+			return errors.New("sway: " + result.Error)
+		}
+	}
+	return nil
+}
+
+// window finds the window of a process in the tree
+func (b *swayBackend) window(pid int) (swayWindow, error) {
+	reply, err := b.request(swayGetTree, "")
+	if err != nil {
+		return swayWindow{}, err
+	}
+	var root swayNode
+	if err := json.Unmarshal(reply, &root); err != nil {
+		return swayWindow{}, err
+	}
+	if window, ok := findSwayWindow(root, pid, false, false); ok {
+		return window, nil
+	}
+	return swayWindow{}, errWindowNotFound
+}
+
+// findSwayWindow searches a subtree for the window of a process
+func findSwayWindow(node swayNode, pid int, floating, hidden bool) (swayWindow, bool) {
+	if node.Type == "workspace" && node.Name == "__i3_scratch" {
+		hidden = true
+	}
+	if node.PID == pid && (node.Type == "con" || node.Type == "floating_con") {
+		return swayWindow{node: node, floating: floating || node.Type == "floating_con", hidden: hidden}, true
+	}
+	for _, child := range node.Nodes {
+		if window, ok := findSwayWindow(child, pid, floating, hidden); ok {
+			return window, true
+		}
+	}
+	for _, child := range node.FloatingNodes {
+		if window, ok := findSwayWindow(child, pid, true, hidden); ok {
+			return window, true
+		}
+	}
+	return swayWindow{}, false
+}
+
+func (b *swayBackend) geometry(pid int) (Rect, error) {
+	window, err := b.window(pid)
+	if err != nil {
+		return Rect{}, err
+	}
+	n := window.node
+	return Rect{
+		X:      n.Rect.X + n.WindowRect.X,
+		Y:      n.Rect.Y + n.WindowRect.Y,
+		Width:  n.WindowRect.Width,
+		Height: n.WindowRect.Height,
+	}, nil
+}
+
+// moveResize places a floating window. sway positions and sizes the
+// container, so the border and title bar around the client area are added.
+func (b *swayBackend) moveResize(pid int, mask uint16, r Rect) error {
+	window, err := b.window(pid)
+	if err != nil {
+		return err
+	}
+	if !window.floating {
+		return errTiled
+	}
+	n := window.node
+
+	if mask&(x11ConfigWidth|x11ConfigHeight) != 0 {
+		width, height := n.WindowRect.Width, n.WindowRect.Height
+		if mask&x11ConfigWidth != 0 {
+			width = r.Width
+		}
+		if mask&x11ConfigHeight != 0 {
+			height = r.Height
+		}
+		err := b.command("[con_id=%d] resize set width %d px height %d px", n.ID,
+			width+n.Rect.Width-n.WindowRect.Width, height+n.Rect.Height-n.WindowRect.Height)
+		if err != nil {
+			return err
+		}
+	}
+	if mask&(x11ConfigX|x11ConfigY) != 0 {
+		x, y := n.Rect.X+n.WindowRect.X, n.Rect.Y+n.WindowRect.Y
+		if mask&x11ConfigX != 0 {
+			x = r.X
+		}
+		if mask&x11ConfigY != 0 {
+			y = r.Y
+		}
+		return b.command("[con_id=%d] move absolute position %d px %d px", n.ID, x-n.WindowRect.X, y-n.WindowRect.Y)
+	}
+	return nil
+}
+
+// state reports fullscreen and scratchpad windows; sway has no maximised state
+func (b *swayBackend) state(pid int) (maximised, fullscreen, hidden bool, err error) {
+	window, err := b.window(pid)
+	if err != nil {
+		return false, false, false, err
+	}
+	return false, window.node.FullscreenMode != 0, window.hidden, nil
+}
+
+func (b *swayBackend) setState(pid int, maximised, fullscreen bool) error {
+	if !fullscreen {
+		return nil // No maximised state in sway
+	}
+	window, err := b.window(pid)
+	if err != nil {
+		return err
+	}
+	return b.command("[con_id=%d] fullscreen enable", window.node.ID)
+}
+
+func (b *swayBackend) activate(pid int) error {
+	window, err := b.window(pid)
+	if err != nil {
+		return err
+	}
+	if window.hidden {
+		return b.command("[con_id=%d] scratchpad show", window.node.ID)
+	}
+	return b.command("[con_id=%d] focus", window.node.ID)
+}
+
+// desktop returns errNoDesktop: workspaces are named and created on demand,
+// not numbered EWMH desktops
+func (b *swayBackend) desktop(pid int) (desktop, count int, err error) {
+	return 0, 0, errNoDesktop
+}
+
+func (b *swayBackend) setDesktop(pid int, desktop int) error {
+	return errNoDesktop
+}
+
+// screens returns the active outputs in logical (scaled) coordinates,
+// ordered by orderOutputs
+func (b *swayBackend) screens() []Rect {
+	reply, err := b.request(swayGetOutputs, "")
+	if err != nil {
+		return nil
+	}
+	var outputs []struct {
+		Name   string `json:"name"`
+		Active bool   `json:"active"`
+		Rect   Rect   `json:"rect"`
+	}
+	if err := json.Unmarshal(reply, &outputs); err != nil {
+		return nil
+	}
+
+	var screens []Rect
+	for _, output := range outputs {
+		if !output.Active || strings.HasPrefix(output.Name, "__") {
+			continue // Disabled, or sway's placeholder for no output
+		}
+		screens = append(screens, output.Rect)
+	}
+	return orderOutputs(screens)
+}
@@ -0,0 +1,167 @@
+//go:build linux
+// +build linux
+
+package modWindowMemory
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// serveUnix answers every connection to a Unix socket in a temp dir with
+// handle and returns the socket path
+func serveUnix(t *testing.T, handle func(conn net.Conn)) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "ipc.sock")
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		t.Skip("unix sockets not available:", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				handle(conn)
+			}()
+		}
+	}()
+	return path
+}
+
+// readTestdata returns a recorded compositor reply from testdata/
+func readTestdata(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// fakeSway serves recorded GET_TREE and GET_OUTPUTS replies over the i3 IPC
+// protocol and records the payloads of RUN_COMMAND messages
+type fakeSway struct {
+	mu       sync.Mutex
+	replies  map[uint32][]byte
+	commands []string
+}
+
+func newFakeSway(t *testing.T) (*fakeSway, *swayBackend) {
+	fake := &fakeSway{replies: map[uint32][]byte{
+		swayGetTree:    readTestdata(t, "sway_get_tree.json"),
+		swayGetOutputs: readTestdata(t, "sway_get_outputs.json"),
+		swayRunCommand: []byte(`[{"success": true}]`),
+	}}
+	return fake, &swayBackend{socket: serveUnix(t, fake.handle)}
+}
+
+func (f *fakeSway) handle(conn net.Conn) {
+	header := make([]byte, len(swayMagic)+8)
+	if _, err := io.ReadFull(conn, header); err != nil || string(header[:len(swayMagic)]) != swayMagic {
+		return
+	}
+	payload := make([]byte, binary.LittleEndian.Uint32(header[len(swayMagic):]))
+	if _, err := io.ReadFull(conn, payload); err != nil {
+		return
+	}
+	kind := binary.LittleEndian.Uint32(header[len(swayMagic)+4:])
+
+	f.mu.Lock()
+	if kind == swayRunCommand {
+		f.commands = append(f.commands, string(payload))
+	}
+	reply := f.replies[kind]
+	f.mu.Unlock()
+
+	binary.LittleEndian.PutUint32(header[len(swayMagic):], uint32(len(reply)))
+	conn.Write(append(header, reply...))
+}
+
+func (f *fakeSway) sent() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.commands...)
+}
+
+func TestSwayScreens(t *testing.T) {
+	_, backend := newFakeSway(t)
+
+	// Focus is on DP-1, but the output at the origin comes first; the
+	// inactive output is left out
+	want := []Rect{
+		{X: 0, Y: 0, Width: 1920, Height: 1080},
+		{X: 1920, Y: 0, Width: 2560, Height: 1440},
+	}
+	if got := backend.screens(); !reflect.DeepEqual(got, want) {
+		t.Errorf("screens() = %v, want %v", got, want)
+	}
+}
+
+func TestSwayWindow(t *testing.T) {
+	_, backend := newFakeSway(t)
+
+	tests := []struct {
+		name       string
+		pid        int
+		geometry   Rect
+		fullscreen bool
+		hidden     bool
+		err        error
+	}{
+		{name: "tiled", pid: 1001, geometry: Rect{X: 2, Y: 2, Width: 956, Height: 1076}},
+		{name: "floating with title bar", pid: 2002, geometry: Rect{X: 102, Y: 108, Width: 1024, Height: 768}},
+		{name: "scratchpad", pid: 3003, geometry: Rect{X: 300, Y: 200, Width: 800, Height: 600}, hidden: true},
+		{name: "unknown process", pid: 4004, err: errWindowNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := backend.geometry(tt.pid)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("geometry() error = %v, want %v", err, tt.err)
+			}
+			if got != tt.geometry {
+				t.Errorf("geometry() = %v, want %v", got, tt.geometry)
+			}
+			if tt.err != nil {
+				return
+			}
+			_, fullscreen, hidden, err := backend.state(tt.pid)
+			if err != nil || fullscreen != tt.fullscreen || hidden != tt.hidden {
+				t.Errorf("state() = fullscreen %v, hidden %v, %v; want %v, %v", fullscreen, hidden, err, tt.fullscreen, tt.hidden)
+			}
+		})
+	}
+}
+
+func TestSwayMoveResize(t *testing.T) {
+	fake, backend := newFakeSway(t)
+
+	if err := backend.moveResize(1001, x11ConfigX|x11ConfigY, Rect{X: 10, Y: 10}); !errors.Is(err, errTiled) {
+		t.Errorf("moveResize() of a tiled window = %v, want errTiled", err)
+	}
+
+	// The container includes the border and title bar around the client area
+	mask := uint16(x11ConfigX | x11ConfigY | x11ConfigWidth | x11ConfigHeight)
+	if err := backend.moveResize(2002, mask, Rect{X: 500, Y: 400, Width: 800, Height: 600}); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"[con_id=17] resize set width 804 px height 630 px",
+		"[con_id=17] move absolute position 498 px 372 px",
+	}
+	if got := fake.sent(); !reflect.DeepEqual(got, want) {
+		t.Errorf("commands = %q, want %q", got, want)
+	}
+}
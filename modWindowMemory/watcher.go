@@ -0,0 +1,141 @@
+package modWindowMemory
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// Continuous position saving
+//
+// SavePosition only runs when the application asks for it, typically when the
+// window closes. If the process is killed or the session ends, every move since
+// launch would be lost. A PositionWatcher samples the window geometry in the
+// background and writes changes to disk once the window has been left alone
+// for the debounce time, so dragging a window doesn't cause a write per sample.
+//
+// Writes go through the same Load/merge/Save cycle and file locking as
+// SavePosition, so several instances can watch their windows at the same time.
+//
+// Usage:
+//
+//	watcher := wpm.Watch(ctx, windowID, storagePath, 2*time.Second, time.Second)
+//	...
+//	watcher.Stop() // On shutdown: writes a pending change, then stops
+type PositionWatcher struct {
+	wpm         *WindowPositionManager
+	ctx         context.Context
+	windowID    string
+	storagePath string
+	interval    time.Duration // Time between two geometry samples
+	debounce    time.Duration // Time a change must be stable before it is written
+
+	mu       sync.Mutex
+	last     *WindowPosition // Last sampled geometry
+	pending  bool            // last differs from what was written
+	changed  time.Time       // When last was sampled
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// Watch starts a background watcher for one window.
+// interval is the sampling period, debounce the quiet time before a change is saved.
+func (wpm *WindowPositionManager) Watch(ctx context.Context, windowID string, storagePath string, interval, debounce time.Duration) *PositionWatcher {
+	w := &PositionWatcher{
+		wpm:         wpm,
+		ctx:         ctx,
+		windowID:    windowID,
+		storagePath: storagePath,
+		interval:    interval,
+		debounce:    debounce,
+		stop:        make(chan struct{}),
+		done:        make(chan struct{}),
+	}
+	go w.run()
+	return w
+}
+
+// run samples the geometry until Stop is called or the context ends
+func (w *PositionWatcher) run() {
+	defer close(w.done)
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			w.sample()
+			w.flushIfQuiet()
+		case <-w.stop:
+			return
+		case <-w.ctx.Done():
+			return
+		}
+	}
+}
+
+// sample reads the current geometry and marks it pending if it changed
+func (w *PositionWatcher) sample() {
+	pos, ok := w.wpm.CurrentPosition(w.ctx)
+	if !ok {
+		return
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.last == nil {
+		// Baseline: the geometry the window was opened with is already saved
+		w.last = &pos
+		return
+	}
+	if sameGeometry(*w.last, pos) {
+		return
+	}
+	w.last = &pos
+	w.pending = true
+	w.changed = time.Now()
+}
+
+// flushIfQuiet writes a pending change once it has been stable for the debounce time
+func (w *PositionWatcher) flushIfQuiet() {
+	w.mu.Lock()
+	quiet := w.pending && time.Since(w.changed) >= w.debounce
+	w.mu.Unlock()
+	if quiet {
+		w.flush()
+	}
+}
+
+// flush writes the pending change, if any
+func (w *PositionWatcher) flush() {
+	w.mu.Lock()
+	if !w.pending || w.last == nil {
+		w.mu.Unlock()
+		return
+	}
+	pos := *w.last
+	w.pending = false
+	w.mu.Unlock()
+
+	if err := w.wpm.saveMeasured(context.Background(), w.windowID, w.storagePath, pos); err != nil && !errors.Is(err, ErrNewerFormat) {
+		println("[WindowPos] Watcher could not save position for", w.windowID, "-", err.Error())
+	}
+}
+
+// Stop ends the watcher and writes a pending change. Safe to call more than once.
+func (w *PositionWatcher) Stop() {
+	w.stopOnce.Do(func() {
+		close(w.stop)
+		<-w.done
+		w.flush()
+	})
+}
+
+// sameGeometry compares the parts of two positions that the watcher tracks
+func sameGeometry(a, b WindowPosition) bool {
+	return a.X == b.X && a.Y == b.Y && a.Width == b.Width && a.Height == b.Height &&
+		a.Maximised == b.Maximised && a.Minimised == b.Minimised && a.Fullscreen == b.Fullscreen &&
+		a.Display == b.Display
+}
@@ -1,11 +1,11 @@
 package modWindowMemory
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
 	"os"
 	"path/filepath"
 	"sync"
-	"time"
 )
 
 // WindowPositionManager handles persistent window positioning across platforms.
@@ -19,29 +19,66 @@ import (
 //   - windowposition_windows.go: Windows-specific geometry handling
 //   - windowposition_linux.go: Linux/GTK-specific geometry handling
 //   - windowposition_darwin.go: macOS-specific geometry handling
+//   - geometry.go, geometry_*.go: Screen list and multi-monitor placement
+//   - state.go: Maximised, fullscreen and minimised state
+//   - watcher.go: Continuous, debounced saving in the background
+//   - storage.go: Atomic writes, backups and recovery of damaged files
+//   - filelock.go, filelock_*.go: Locks shared with other instances
+//   - format.go: Versioned file format and migrations
 //
 // Usage in any Wails project:
 //  1. Create manager: wpm := NewWindowPositionManager()
 //  2. Load saved state: wpm.Load(storagePath)
 //  3. On app startup: wpm.RestorePosition(ctx, windowID)
 //  4. On app shutdown: wpm.SavePosition(ctx, windowID, storagePath)
+//  5. Optional: w := wpm.Watch(...) saves moves while running, w.Stop() on shutdown
 //
 // Window IDs are typically the window title, allowing multiple windows to be tracked.
 type WindowPositionManager struct {
 	positions map[string]*WindowPosition
-	xOffset   int          // Platform-specific offset X (e.g., Windows border)
-	yOffset   int          // Platform-specific offset Y (e.g., Windows titlebar)
-	mu        sync.RWMutex // Protects positions map from concurrent access
+	xOffset   int             // Platform-specific offset X (e.g., Windows border)
+	yOffset   int             // Platform-specific offset Y (e.g., Windows titlebar)
+	mu        sync.RWMutex    // Protects positions map from concurrent access
+	recovery  *RecoveryReport // Last repair of a damaged file by Load, nil if none
+	runtime   WindowRuntime   // Set by SetRuntime, nil for the Wails runtime
+	applied   int             // Positions applied so far; a newer one stops the drift correction (Linux)
+
+	placement PlacementStrategy // How windows without a saved position are placed, see placement.go
+	siblings  SiblingsFunc      // Geometry of the other windows, for placement
 }
 
 // WindowPosition stores position and size for a single window
 type WindowPosition struct {
-	X      int `json:"x"`
-	Y      int `json:"y"`
-	Width  int `json:"width"`
-	Height int `json:"height"`
+	X      int   `json:"x"`
+	Y      int   `json:"y"`
+	Width  int   `json:"width"`
+	Height int   `json:"height"`
+	Screen *Rect `json:"screen,omitempty"` // Screen the window was on, see geometry.go
+
+	// Scale is the scale factor (physical / logical pixels) of Screen when
+	// the position was saved; 0 if unknown. See scale.go.
+	Scale float64 `json:"scale,omitempty"`
+
+	// Window state; X/Y/Width/Height are the normal bounds, see state.go
+	Maximised  bool `json:"maximised,omitempty"`
+	Minimised  bool `json:"minimised,omitempty"`
+	Fullscreen bool `json:"fullscreen,omitempty"`
+
+	// Desktop is the virtual desktop (EWMH workspace, counted from 0) the
+	// window was on; nil if unknown or not supported. Linux only.
+	Desktop *int `json:"desktop,omitempty"`
+
+	// Display is the fingerprint of the display configuration the position was
+	// saved in; Displays holds the last position for each configuration
+	Display  string                     `json:"display,omitempty"`
+	Displays map[string]*WindowPosition `json:"displays,omitempty"`
 }
 
+// ErrNoBackend is returned by WindowBackend when no backend can read or move
+// windows in this session, e.g. on a Wayland compositor without IPC. The Wails
+// runtime is still used then, but positions are usually not restored.
+var ErrNoBackend = errors.New("no window backend available")
+
 // NewWindowPositionManager creates a new window position manager
 func NewWindowPositionManager() *WindowPositionManager {
 	return &WindowPositionManager{
@@ -51,6 +88,10 @@ func NewWindowPositionManager() *WindowPositionManager {
 
 // Load reads saved window positions from disk
 // storagePath: full path to JSON file (e.g., "path/to/windows.json")
+//
+// A damaged file is moved aside and the newest readable backup is loaded
+// instead (see storage.go). The repair is available through Recovery(); Load
+// returns the *RecoveryReport as error only if no backup was usable.
 func (wpm *WindowPositionManager) Load(storagePath string) error {
 	wpm.mu.Lock()
 	defer wpm.mu.Unlock()
@@ -61,52 +102,179 @@ func (wpm *WindowPositionManager) Load(storagePath string) error {
 		return err
 	}
 
+	ctx, cancel := context.WithTimeout(context.Background(), lockTimeout)
+	defer cancel()
+
 	// Use file locking to prevent race conditions with other instances
-	file, err := openWithLock(storagePath, os.O_RDONLY, false)
+	lock, err := lockStorage(ctx, storagePath, false)
 	if err != nil {
-		if os.IsNotExist(err) {
-			return nil // File doesn't exist yet, not an error
-		}
 		return err
 	}
-	defer file.Close()
+	defer lock.Unlock()
 
 	data, err := os.ReadFile(storagePath)
 	if err != nil {
+		if os.IsNotExist(err) {
+			return nil // File doesn't exist yet, not an error
+		}
 		return err
 	}
 
-	return json.Unmarshal(data, &wpm.positions)
+	positions, version, err := decodePositions(data)
+	if err != nil || version < currentFormatVersion {
+		// Repairs and upgrades rewrite the file and need the exclusive lock;
+		// another instance may have done it in the meantime, so read again
+		lock.Unlock()
+		exclusive, err := lockStorage(ctx, storagePath, true)
+		if err != nil {
+			return err
+		}
+		defer exclusive.Unlock()
+
+		var report *RecoveryReport
+		if positions, version, report, err = readStorage(storagePath); err != nil {
+			return err
+		}
+		if report != nil {
+			wpm.recovery = report
+			wpm.mergePositions(positions)
+			if !report.Recovered() {
+				return report
+			}
+			return nil
+		}
+	}
+
+	if version > currentFormatVersion {
+		println("[WindowPos] Warning:", storagePath, "has format version", version, "from a newer release - positions won't be saved")
+	}
+
+	wpm.mergePositions(positions)
+	return nil
+}
+
+// mergePositions adds loaded positions to the map; called with wpm.mu held
+func (wpm *WindowPositionManager) mergePositions(positions map[string]*WindowPosition) {
+	for windowID, pos := range positions {
+		wpm.positions[windowID] = pos
+	}
+}
+
+// Recovery returns the report of the last repair done by Load, or nil
+func (wpm *WindowPositionManager) Recovery() *RecoveryReport {
+	wpm.mu.RLock()
+	defer wpm.mu.RUnlock()
+	return wpm.recovery
 }
 
 // Save writes current window positions to disk
 // storagePath: full path to JSON file (e.g., "path/to/windows.json")
+//
+// The file is replaced atomically and the previous version is kept as backup.
+// If another instance holds the lock for longer than a few seconds, a
+// *LockError is returned and nothing is written.
 func (wpm *WindowPositionManager) Save(storagePath string) error {
+	if err := os.MkdirAll(filepath.Dir(storagePath), 0755); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), lockTimeout)
+	defer cancel()
+
+	lock, err := lockStorage(ctx, storagePath, true)
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	return wpm.writeStorage(storagePath)
+}
+
+// writeStorage writes all positions of the manager to the file. Called with
+// the exclusive lock held.
+func (wpm *WindowPositionManager) writeStorage(storagePath string) error {
 	wpm.mu.RLock()
-	data, err := json.Marshal(wpm.positions)
+	data, err := encodePositions(wpm.positions)
 	wpm.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+
+	// Never overwrite a format this version doesn't fully understand
+	if readVersion(storagePath) > currentFormatVersion {
+		return ErrNewerFormat
+	}
+
+	rotateBackups(storagePath)
+	return writeFileAtomic(storagePath, data)
+}
 
+// ErrNoChange ends an Update transaction without writing the file
+var ErrNoChange = errors.New("no change to save")
+
+// Update changes the positions file in one read-modify-write transaction.
+// With the exclusive lock held, the manager's positions are replaced by those
+// in the file, fn changes them through the manager's methods, and they are
+// written back (like Save). No other instance can write the file in between,
+// so their positions are never lost, and positions they removed don't come
+// back. Changes made to the manager outside of Update and not saved are
+// dropped.
+//
+// If fn returns an error, nothing is written and the error is returned;
+// ErrNoChange ends the transaction without writing and without error. ctx
+// bounds the wait for the lock: a *LockError (errors.Is ErrLockContended) is
+// returned if it ends first. Files written by a newer version are read, but
+// fn isn't called and ErrNewerFormat is returned.
+func (wpm *WindowPositionManager) Update(ctx context.Context, storagePath string, fn func() error) error {
+	if err := os.MkdirAll(filepath.Dir(storagePath), 0755); err != nil {
+		return err
+	}
+
+	lock, err := lockStorage(ctx, storagePath, true)
 	if err != nil {
 		return err
 	}
+	defer lock.Unlock()
 
-	// Use file locking with retry to handle concurrent writes from multiple instances
-	for attempts := 0; attempts < 5; attempts++ {
-		file, err := openWithLock(storagePath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, true)
-		if err != nil {
-			if attempts < 4 {
-				time.Sleep(time.Millisecond * 50) // Wait before retry
-				continue
-			}
-			return err
-		}
+	positions, version, report, err := readStorage(storagePath)
+	if err != nil {
+		return err
+	}
+	if positions == nil {
+		positions = make(map[string]*WindowPosition) // No file yet
+	}
+	wpm.mu.Lock()
+	if report != nil {
+		wpm.recovery = report
+	}
+	wpm.positions = positions
+	wpm.mu.Unlock()
+
+	if version > currentFormatVersion {
+		return ErrNewerFormat
+	}
 
-		_, err = file.Write(data)
-		file.Close()
+	if err := fn(); err != nil {
+		if errors.Is(err, ErrNoChange) {
+			return nil
+		}
 		return err
 	}
+	return wpm.writeStorage(storagePath)
+}
 
-	return nil
+// saveMeasured merges a position read from the window into the file in one
+// transaction, preserving positions saved by other running instances
+func (wpm *WindowPositionManager) saveMeasured(ctx context.Context, windowID string, storagePath string, pos WindowPosition) error {
+	ctx, cancel := context.WithTimeout(ctx, lockTimeout)
+	defer cancel()
+
+	return wpm.Update(ctx, storagePath, func() error {
+		if !wpm.storeCurrentPosition(windowID, pos) {
+			return ErrNoChange // Minimised and never saved before - nothing to keep
+		}
+		return nil
+	})
 }
 
 // RestorePosition restores window position for a given window ID.
@@ -141,55 +309,42 @@ func (wpm *WindowPositionManager) SetPosition(pageID string, x, y, width, height
 	}
 }
 
-// validateAndCorrectPosition ensures window position is within visible screen bounds.
-// Returns corrected position and size to keep the window fully visible.
-//
-// Parameters:
-//   - x, y: requested window position
-//   - width, height: window dimensions
-//   - screenWidth, screenHeight: primary screen dimensions
-//
-// Returns corrected x, y, width, height coordinates.
-func validateAndCorrectPosition(x, y, width, height, screenWidth, screenHeight int) (int, int, int, int) {
-	correctedX := x
-	correctedY := y
-	correctedWidth := width
-	correctedHeight := height
-
-	// First, ensure window size fits within screen
-	if correctedWidth > screenWidth {
-		correctedWidth = screenWidth
-		println("[WindowPos] Width reduced from", width, "to", correctedWidth, "to fit screen")
-	}
-	if correctedHeight > screenHeight {
-		correctedHeight = screenHeight
-		println("[WindowPos] Height reduced from", height, "to", correctedHeight, "to fit screen")
-	}
-
-	// Then, adjust position to keep window fully visible
-	// Check left edge
-	if correctedX < 0 {
-		correctedX = 0
-	}
+// SetWindowPosition stores a complete position for a page ID (doesn't save to disk).
+// The position also becomes the entry for its display configuration; entries of
+// other configurations are kept.
+func (wpm *WindowPositionManager) SetWindowPosition(pageID string, pos WindowPosition) {
+	wpm.mu.Lock()
+	defer wpm.mu.Unlock()
 
-	// Check right edge
-	if correctedX+correctedWidth > screenWidth {
-		correctedX = screenWidth - correctedWidth
+	displays := make(map[string]*WindowPosition)
+	if old := wpm.positions[pageID]; old != nil {
+		for fingerprint, entry := range old.Displays {
+			displays[fingerprint] = entry
+		}
 	}
-
-	// Check top edge
-	if correctedY < 0 {
-		correctedY = 0
+	if pos.Display != "" {
+		entry := pos
+		entry.Displays = nil
+		displays[pos.Display] = &entry
 	}
 
-	// Check bottom edge
-	if correctedY+correctedHeight > screenHeight {
-		correctedY = screenHeight - correctedHeight
+	pos.Displays = nil
+	if len(displays) > 0 {
+		pos.Displays = displays
 	}
+	wpm.positions[pageID] = &pos
+}
 
-	if correctedX != x || correctedY != y || correctedWidth != width || correctedHeight != height {
-		println("[WindowPos] Position/Size corrected from (", x, ",", y, ",", width, "x", height, ") to (", correctedX, ",", correctedY, ",", correctedWidth, "x", correctedHeight, ")")
-	}
+// RemovePosition deletes the saved position for a page ID (doesn't save to disk)
+func (wpm *WindowPositionManager) RemovePosition(pageID string) {
+	wpm.mu.Lock()
+	defer wpm.mu.Unlock()
+	delete(wpm.positions, pageID)
+}
 
-	return correctedX, correctedY, correctedWidth, correctedHeight
+// ClearPositions deletes all saved positions (doesn't save to disk)
+func (wpm *WindowPositionManager) ClearPositions() {
+	wpm.mu.Lock()
+	defer wpm.mu.Unlock()
+	wpm.positions = make(map[string]*WindowPosition)
 }
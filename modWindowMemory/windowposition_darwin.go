@@ -5,19 +5,22 @@ package modWindowMemory
 
 import (
 	"context"
-
-	"github.com/wailsapp/wails/v2/pkg/runtime"
+	"errors"
 )
 
 // macOS-specific window position management
 //
 // macOS coordinate system characteristics:
 // - Origin (0,0) is at bottom-left of primary screen (unlike Windows/Linux top-left)
-// - Wails abstracts this, but multi-monitor setups may have quirks
+// - Wails positions are relative to the visible frame of the window's screen;
+//   the Wails runtime converts them to desktop coordinates in points, with
+//   the NSScreen frames as screens (desktopWindow, desktop.go)
+// - Points are logical pixels, so saved geometry is never converted between
+//   screen scales (scale.go)
 // - Window decorations are handled by the OS consistently
 //
 // This implementation:
-// 1. Uses Wails runtime methods directly (they work reliably on macOS)
+// 1. Sets the geometry through the window runtime (Wails works reliably on macOS)
 // 2. No offset compensation needed (macOS is consistent)
 // 3. No special fallback mechanisms required
 
@@ -29,59 +32,82 @@ func (wpm *WindowPositionManager) RestorePosition(ctx context.Context, windowID
 
 	if !exists || pos == nil || pos.Width == 0 || pos.Height == 0 {
 		println("[WindowPos] No saved position for", windowID)
+		if placed, ok := wpm.newWindowPosition(ctx); ok {
+			wpm.applyPosition(ctx, placed)
+		}
 		return
 	}
 
 	println("[WindowPos] Restoring position for", windowID, "- X:", pos.X, "Y:", pos.Y, "W:", pos.Width, "H:", pos.Height)
+	wpm.applyPosition(ctx, *pos)
+}
 
-	// Get screen dimensions to validate position
-	screens, err := runtime.ScreenGetAll(ctx)
-	if err == nil && len(screens) > 0 {
-		// Use primary screen dimensions
-		screenWidth := screens[0].Width
-		screenHeight := screens[0].Height
-
-		// Validate and correct position to stay within screen bounds
-		pos.X, pos.Y, pos.Width, pos.Height = validateAndCorrectPosition(pos.X, pos.Y, pos.Width, pos.Height, screenWidth, screenHeight)
-	} else {
-		println("[WindowPos] Warning: Could not get screen dimensions, skipping bounds validation")
-	}
+// WindowBackend returns the name of the backend that reads and moves windows
+// (macOS implementation): always "wails", the Wails runtime is always available
+func WindowBackend() (string, error) {
+	return "wails", nil
+}
+
+// ApplyPosition moves the window of a running application to pos (macOS implementation)
+func (wpm *WindowPositionManager) ApplyPosition(ctx context.Context, windowID string, pos WindowPosition) {
+	println("[WindowPos] Applying position for", windowID, "- X:", pos.X, "Y:", pos.Y, "W:", pos.Width, "H:", pos.Height)
+	wpm.applyPosition(ctx, pos)
+}
+
+// applyPosition validates pos against the screen and sets it
+func (wpm *WindowPositionManager) applyPosition(ctx context.Context, pos WindowPosition) {
+	rt := wpm.window(ctx)
+
+	// Keep the window on its saved screen (or the nearest one) and fully visible
+	screens := rt.Screens()
+	pos = placeOnScreens(pos.forDisplays(screens), screens)
+
+	// Bounds can only be set on a normal window
+	resetWindowState(rt)
 
 	// macOS: Simple and reliable
-	runtime.WindowSetPosition(ctx, pos.X, pos.Y)
-	runtime.WindowSetSize(ctx, pos.Width, pos.Height)
+	rt.SetPosition(pos.X, pos.Y)
+	rt.SetSize(pos.Width, pos.Height)
+	applyWindowState(rt, pos)
 }
 
-// SavePosition saves current window position (macOS implementation)
-func (wpm *WindowPositionManager) SavePosition(ctx context.Context, windowID string, storagePath string) {
+// CurrentPosition returns the current window geometry (macOS implementation).
+// ok is false if the window has no valid size (e.g. during shutdown).
+func (wpm *WindowPositionManager) CurrentPosition(ctx context.Context) (pos WindowPosition, ok bool) {
 	defer func() {
 		if r := recover(); r != nil {
-			println("[WindowPos] Recovered from panic during save:", r)
+			println("[WindowPos] Recovered from panic while reading geometry:", r)
+			ok = false
 		}
 	}()
 
-	x, y := runtime.WindowGetPosition(ctx)
-	width, height := runtime.WindowGetSize(ctx)
+	rt := wpm.window(ctx)
+	x, y := rt.Position()
+	width, height := rt.Size()
+	pos = WindowPosition{X: x, Y: y, Width: width, Height: height}
+	readWindowState(rt, &pos)
+
+	// Minimised windows report no usable size, their bounds come from the last save
+	if !pos.Minimised && (width == 0 || height == 0) {
+		return WindowPosition{}, false
+	}
+	return withScreen(pos, rt.Screens()), true
+}
+
+// SavePosition saves current window position (macOS implementation)
+func (wpm *WindowPositionManager) SavePosition(ctx context.Context, windowID string, storagePath string) {
+	pos, ok := wpm.CurrentPosition(ctx)
 
 	// Don't save invalid dimensions (happens during shutdown)
-	if width == 0 || height == 0 {
+	if !ok {
 		println("[WindowPos] Skipping save - invalid dimensions")
 		return
 	}
 
-	println("[WindowPos] Saving position for", windowID, "- X:", x, "Y:", y, "W:", width, "H:", height)
+	println("[WindowPos] Saving position for", windowID, "- X:", pos.X, "Y:", pos.Y, "W:", pos.Width, "H:", pos.Height, "Maximised:", pos.Maximised, "Fullscreen:", pos.Fullscreen, "Minimised:", pos.Minimised)
 
-	// Reload from disk to preserve positions of other running instances
-	wpm.Load(storagePath)
-
-	wpm.mu.Lock()
-	wpm.positions[windowID] = &WindowPosition{
-		X:      x,
-		Y:      y,
-		Width:  width,
-		Height: height,
+	// Merge into the file in one transaction to preserve positions of other running instances
+	if err := wpm.saveMeasured(ctx, windowID, storagePath, pos); err != nil && !errors.Is(err, ErrNewerFormat) {
+		println("[WindowPos] Could not save position for", windowID, "-", err.Error())
 	}
-	wpm.mu.Unlock()
-
-	wpm.Save(storagePath)
 }
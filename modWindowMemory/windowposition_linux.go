@@ -5,11 +5,9 @@ package modWindowMemory
 
 import (
 	"context"
-	"os/exec"
-	"strconv"
-	"strings"
-
-	"github.com/wailsapp/wails/v2/pkg/runtime"
+	"errors"
+	"os"
+	"time"
 )
 
 // Linux-specific window position management
@@ -21,109 +19,154 @@ import (
 //
 // This implementation provides fallback mechanisms:
 // 1. Try Wails runtime methods first (may work on some GTK versions)
-// 2. Fall back to xdotool if available (reads from X11 directly)
-// 3. Gracefully handle missing xdotool by skipping save
+// 2. Fall back to a window backend that asks the display server directly
+//    (backend_linux.go): the built-in X11 client, xdotool, or the IPC socket
+//    of sway or Hyprland on Wayland
+// 3. Gracefully handle a missing backend by skipping save
+//
+// No external tools are required on X11.
+//
+// The virtual desktop of the window (_NET_WM_DESKTOP) is saved too. On restore
+// the window is moved back to it if it still exists; window managers without
+// EWMH desktops and the Wayland compositors simply leave the window where it
+// opens.
 //
-// Requirements for full functionality:
-// - xdotool must be installed via package manager:
-//   Debian/Ubuntu: sudo apt-get install xdotool
-//   openSUSE/SUSE: sudo zypper install xdotool
-//   Fedora/RHEL: sudo dnf install xdotool
-//   Arch Linux: sudo pacman -S xdotool
-// - X11 display server (Wayland support may vary)
-
-// getLinuxWindowGeometry attempts to get window geometry using xdotool
-// This bypasses GTK/Wails issues by querying X11 directly
+// Only the window of the current process is ever read or moved. It is found
+// through its PID (_NET_WM_PID on X11, which GTK sets on its windows), so
+// several instances never act on each other's windows, nor on unrelated
+// windows with a similar title. Wails doesn't expose the GTK window's X11 ID,
+// so the PID is the only reliable link between the process and its window.
+
+// getLinuxWindowGeometry reads the geometry of this process's window through
+// the window backend (backend_linux.go). This bypasses GTK/Wails issues.
 func getLinuxWindowGeometry(dbg bool) (x, y, width, height int, ok bool) {
-	if dbg {
-		println("[WindowPos][DEBUG] getLinuxWindowGeometry() called")
+	backend, err := linuxBackend()
+	if err != nil {
+		return 0, 0, 0, 0, false
 	}
-	// Search for window by title pattern - get only the first/active window
-	cmd := exec.Command("xdotool", "search", "--name", "^SimpleAI", "getwindowgeometry", "--shell")
-	output, err := cmd.Output()
+	r, err := backend.geometry(os.Getpid())
 	if err != nil {
-		// xdotool not installed or window not found
 		if dbg {
-			println("[WindowPos][DEBUG] xdotool command failed:", err.Error())
+			println("[WindowPos][DEBUG]", backend.name(), "geometry failed:", err.Error())
 		}
 		return 0, 0, 0, 0, false
 	}
+	x, y, width, height = r.X, r.Y, r.Width, r.Height
 
-	outputStr := string(output)
 	if dbg {
-		println("[WindowPos][DEBUG] xdotool raw output:", outputStr)
+		println("[WindowPos][DEBUG] Read values - X:", x, "Y:", y, "W:", width, "H:", height)
 	}
 
-	// Parse xdotool output format:
-	// WINDOW=123456
-	// X=100
-	// Y=200
-	// WIDTH=800
-	// HEIGHT=600
-	lines := strings.Split(outputStr, "\n")
+	// Validate that we got reasonable values
+	// Reject suspiciously small windows (10x10 is typically a destroyed/closing window)
+	if width > 50 && height > 50 {
+		// Accept even if X/Y are 0 - could be valid screen position
+		return x, y, width, height, true
+	}
 
-	// Variables to track if we found all required values
-	foundX, foundY, foundWidth, foundHeight := false, false, false, false
+	if dbg {
+		println("[WindowPos][DEBUG] Invalid or incomplete geometry data (too small or missing)")
+	}
+	return 0, 0, 0, 0, false
+}
 
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if line == "" {
-			continue
-		}
+// moveResizeLinuxWindow sets the parts of this process's window geometry
+// selected by mask (x11ConfigX, x11ConfigY, x11ConfigWidth, x11ConfigHeight)
+func moveResizeLinuxWindow(mask uint16, x, y, width, height int) error {
+	backend, err := linuxBackend()
+	if err != nil {
+		return err
+	}
+	return backend.moveResize(os.Getpid(), mask, Rect{X: x, Y: y, Width: width, Height: height})
+}
 
-		parts := strings.Split(line, "=")
-		if len(parts) != 2 {
-			if dbg {
-				println("[WindowPos][DEBUG] Skipping malformed line:", line)
-			}
-			continue
-		}
+// getLinuxWindowState reads the window state as the window manager or
+// compositor sees it (_NET_WM_STATE on X11)
+func getLinuxWindowState(dbg bool) (maximised, fullscreen, hidden, ok bool) {
+	backend, err := linuxBackend()
+	if err != nil {
+		return false, false, false, false
+	}
+	maximised, fullscreen, hidden, err = backend.state(os.Getpid())
+	if err != nil && dbg {
+		println("[WindowPos][DEBUG]", backend.name(), "window state failed:", err.Error())
+	}
+	return maximised, fullscreen, hidden, err == nil
+}
 
-		key := strings.TrimSpace(parts[0])
-		valueStr := strings.TrimSpace(parts[1])
-		val, err := strconv.Atoi(valueStr)
-		if err != nil {
-			if dbg {
-				println("[WindowPos][DEBUG] Failed to parse value for", key, ":", valueStr)
-			}
-			continue
-		}
+// linuxBackendPhysical reports whether the window backend reads and sets
+// geometry in physical pixels, which depends on the screen's scale
+func linuxBackendPhysical() bool {
+	backend, err := linuxBackend()
+	return err == nil && backend.physicalPixels()
+}
 
-		switch key {
-		case "X":
-			x = val
-			foundX = true
-		case "Y":
-			y = val
-			foundY = true
-		case "WIDTH":
-			width = val
-			foundWidth = true
-		case "HEIGHT":
-			height = val
-			foundHeight = true
-		}
+// setLinuxWindowState asks the window manager or compositor for the
+// maximised or fullscreen state. Errors are ignored: the Wails runtime has
+// already been asked to do the same.
+func setLinuxWindowState(maximised, fullscreen bool, dbg bool) {
+	backend, err := linuxBackend()
+	if err != nil {
+		return
 	}
+	if err := backend.setState(os.Getpid(), maximised, fullscreen); err != nil && dbg {
+		println("[WindowPos][DEBUG] Setting window state - Maximised:", maximised, "Fullscreen:", fullscreen, "failed:", err.Error())
+	}
+}
 
-	if dbg {
-		println("[WindowPos][DEBUG] Parsed values - X:", x, "Y:", y, "W:", width, "H:", height)
-		println("[WindowPos][DEBUG] Found flags - X:", foundX, "Y:", foundY, "W:", foundWidth, "H:", foundHeight)
+// getLinuxWindowDesktop reads the virtual desktop of the window
+// (_NET_WM_DESKTOP on X11). ok is false without EWMH desktops.
+func getLinuxWindowDesktop(dbg bool) (desktop int, ok bool) {
+	backend, err := linuxBackend()
+	if err != nil {
+		return 0, false
 	}
+	desktop, _, err = backend.desktop(os.Getpid())
+	if err != nil {
+		if dbg {
+			println("[WindowPos][DEBUG]", backend.name(), "desktop failed:", err.Error())
+		}
+		return 0, false
+	}
+	return desktop, true
+}
 
-	// Validate that we got reasonable values
-	// Reject suspiciously small windows (10x10 is typically a destroyed/closing window)
-	if foundWidth && foundHeight && width > 50 && height > 50 {
-		// Accept even if X/Y are 0 - could be valid screen position
+// moveLinuxWindowToDesktop moves the window back to a saved virtual desktop,
+// as long as that desktop still exists. Does nothing without EWMH desktops.
+func moveLinuxWindowToDesktop(desktop int, dbg bool) {
+	backend, err := linuxBackend()
+	if err != nil {
+		return
+	}
+	current, count, err := backend.desktop(os.Getpid())
+	if err != nil {
 		if dbg {
-			println("[WindowPos][DEBUG] Valid geometry found, returning values")
+			println("[WindowPos][DEBUG] Not restoring desktop", desktop, "-", err.Error())
 		}
-		return x, y, width, height, true
+		return
+	}
+	if desktop == current {
+		return
 	}
+	if desktop >= count {
+		println("[WindowPos] Desktop", desktop, "no longer exists (", count, "desktops ), window stays on desktop", current)
+		return
+	}
+	if err := backend.setDesktop(os.Getpid(), desktop); err != nil && dbg {
+		println("[WindowPos][DEBUG] Moving window to desktop", desktop, "failed:", err.Error())
+	}
+}
 
-	if dbg {
-		println("[WindowPos][DEBUG] Invalid or incomplete geometry data (too small or missing)")
+// ActivateProcessWindow raises and focuses the window of a process through
+// the window backend: _NET_ACTIVE_WINDOW on X11, a focus command on sway and
+// Hyprland. Works on window managers that ignore the show and focus requests
+// of GTK. Returns an error matching ErrNoBackend if there is no backend.
+func ActivateProcessWindow(pid int) error {
+	backend, err := linuxBackend()
+	if err != nil {
+		return err
 	}
-	return 0, 0, 0, 0, false
+	return backend.activate(pid)
 }
 
 // RestorePosition restores window position (Linux implementation)
@@ -142,6 +185,9 @@ func (wpm *WindowPositionManager) RestorePosition(ctx context.Context, windowID
 		if dbg {
 			println("[WindowPos][DEBUG] No saved position for", windowID)
 		}
+		if placed, ok := wpm.newWindowPosition(ctx); ok {
+			wpm.applyPosition(ctx, placed, dbg)
+		}
 		return
 	}
 
@@ -149,49 +195,87 @@ func (wpm *WindowPositionManager) RestorePosition(ctx context.Context, windowID
 		println("[WindowPos][DEBUG] Restoring position for", windowID, "- X:", pos.X, "Y:", pos.Y, "W:", pos.Width, "H:", pos.Height)
 	}
 
-	// Get screen dimensions to validate position
-	screens, err := runtime.ScreenGetAll(ctx)
-	if err == nil && len(screens) > 0 {
-		// Use primary screen dimensions
-		screenWidth := screens[0].Width
-		screenHeight := screens[0].Height
+	wpm.applyPosition(ctx, *pos, dbg)
+}
 
-		if dbg {
-			println("[WindowPos][DEBUG] Screen dimensions:", screenWidth, "x", screenHeight)
-			println("[WindowPos][DEBUG] Before validation - X:", pos.X, "Y:", pos.Y, "W:", pos.Width, "H:", pos.Height)
-		}
+// ApplyPosition moves the window of a running application to pos (Linux implementation).
+// Like all Linux operations it acts on the window of the current process;
+// windowID is the ID the position is known by.
+func (wpm *WindowPositionManager) ApplyPosition(ctx context.Context, windowID string, pos WindowPosition) {
+	const dbg = false // Set to true to enable detailed debug logging
 
-		// Validate and correct position to stay within screen bounds
-		pos.X, pos.Y, pos.Width, pos.Height = validateAndCorrectPosition(pos.X, pos.Y, pos.Width, pos.Height, screenWidth, screenHeight)
+	if dbg {
+		println("[WindowPos][DEBUG] ApplyPosition() called for", windowID, "- X:", pos.X, "Y:", pos.Y, "W:", pos.Width, "H:", pos.Height)
+	}
+	wpm.applyPosition(ctx, pos, dbg)
+}
 
-		if dbg {
-			println("[WindowPos][DEBUG] After validation - X:", pos.X, "Y:", pos.Y, "W:", pos.Width, "H:", pos.Height)
-		}
+// applyPosition validates pos against the screen and sets it through the window
+// runtime and, for the application's own window, through the window backend
+func (wpm *WindowPositionManager) applyPosition(ctx context.Context, pos WindowPosition, dbg bool) {
+	rt := wpm.window(ctx)
+
+	// Keep the window on its saved screen (or the nearest one) and fully visible
+	if dbg {
+		println("[WindowPos][DEBUG] Before validation - X:", pos.X, "Y:", pos.Y, "W:", pos.Width, "H:", pos.Height)
+	}
+	// Only geometry in physical pixels needs converting to the screen's scale;
+	// the backend sets it for the application's own window
+	screens := rt.Screens()
+	if isWailsRuntime(rt) && linuxBackendPhysical() {
+		pos = placeScaled(pos.forDisplays(screens), screens, rt)
 	} else {
-		if dbg {
-			println("[WindowPos][DEBUG] Warning: Could not get screen dimensions, skipping bounds validation")
-		}
+		pos = placeOnScreens(pos.forDisplays(screens), screens)
 	}
+	if dbg {
+		println("[WindowPos][DEBUG] After validation - X:", pos.X, "Y:", pos.Y, "W:", pos.Width, "H:", pos.Height)
+	}
+
+	// Bounds can only be set on a normal window
+	resetWindowState(rt)
 
-	// Try Wails runtime methods first (may work on some GTK versions)
+	// Try the window runtime first (Wails may work on some GTK versions)
 	// For GTK, set size before position (order matters)
 	if dbg {
-		println("[WindowPos][DEBUG] Calling runtime.WindowSetSize(", pos.Width, ",", pos.Height, ")")
+		println("[WindowPos][DEBUG] Calling SetSize(", pos.Width, ",", pos.Height, ")")
 	}
-	runtime.WindowSetSize(ctx, pos.Width, pos.Height)
+	rt.SetSize(pos.Width, pos.Height)
 
 	if dbg {
-		println("[WindowPos][DEBUG] Calling runtime.WindowSetPosition(", pos.X, ",", pos.Y, ")")
+		println("[WindowPos][DEBUG] Calling SetPosition(", pos.X, ",", pos.Y, ")")
+	}
+	rt.SetPosition(pos.X, pos.Y)
+
+	// Older drift corrections stop once this position is applied. The
+	// monitoring time is copied before they start, tests shorten it.
+	wpm.mu.Lock()
+	wpm.applied++
+	applied := wpm.applied
+	wpm.mu.Unlock()
+	checks, interval := driftChecks, driftInterval
+
+	// The backend moves this process's window, not an injected runtime's:
+	// an injected runtime gets the drift correction directly
+	if !isWailsRuntime(rt) {
+		applyWindowState(rt, pos)
+		if !pos.Maximised && !pos.Fullscreen {
+			go wpm.correctDrift(rt, pos, applied, checks, interval, dbg)
+		}
+		return
 	}
-	runtime.WindowSetPosition(ctx, pos.X, pos.Y)
 
-	// Apply position using xdotool with polling and timeout
+	// Apply position through the window backend with polling and timeout
 	go func() {
-		const maxAttempts = 50   // 50 attempts at 100ms = 5 seconds timeout
-		const pollInterval = 100 // milliseconds
+		const maxAttempts = 50 // 50 attempts at 100ms = 5 seconds timeout
+		const pollInterval = 100 * time.Millisecond
 
 		if dbg {
-			println("[WindowPos][DEBUG] Starting xdotool goroutine")
+			println("[WindowPos][DEBUG] Starting window backend goroutine")
+		}
+
+		// No backend: the Wails runtime calls above are all we can do
+		if _, err := linuxBackend(); err != nil {
+			return
 		}
 
 		// Wait for window to be ready
@@ -201,16 +285,13 @@ func (wpm *WindowPositionManager) RestorePosition(ctx context.Context, windowID
 				println("[WindowPos][DEBUG] Polling for window, attempt", attempt)
 			}
 			// Check if window exists and is ready
-			cmd := exec.Command("xdotool", "search", "--name", "^SimpleAI")
-			if output, err := cmd.Output(); err == nil && len(output) > 0 {
-				windowFound = true
+			if _, _, _, _, windowFound = getLinuxWindowGeometry(false); windowFound {
 				if dbg {
 					println("[WindowPos][DEBUG] Window found after", attempt, "attempts")
 				}
 				break
 			}
-			// Poll every 100ms
-			exec.Command("sleep", "0.1").Run()
+			time.Sleep(pollInterval)
 		}
 
 		if !windowFound {
@@ -220,161 +301,218 @@ func (wpm *WindowPositionManager) RestorePosition(ctx context.Context, windowID
 			return
 		}
 
-		// Apply position now that window is ready
-		if dbg {
-			println("[WindowPos][DEBUG] Applying position with xdotool windowmove", pos.X, pos.Y)
+		// Back to the saved virtual desktop before placing the window there
+		if pos.Desktop != nil {
+			moveLinuxWindowToDesktop(*pos.Desktop, dbg)
 		}
 
-		cmd := exec.Command("xdotool", "search", "--name", "^SimpleAI", "windowmove",
-			strconv.Itoa(pos.X), strconv.Itoa(pos.Y))
-		if err := cmd.Run(); err != nil {
+		// Apply position and size now that window is ready
+		if dbg {
+			println("[WindowPos][DEBUG] Applying geometry", pos.X, pos.Y, pos.Width, pos.Height)
+		}
+		err := moveResizeLinuxWindow(x11ConfigX|x11ConfigY|x11ConfigWidth|x11ConfigHeight,
+			pos.X, pos.Y, pos.Width, pos.Height)
+		if err != nil {
 			if dbg {
-				println("[WindowPos][DEBUG] ERROR: Failed to set position:", err.Error())
+				println("[WindowPos][DEBUG] ERROR: Failed to set geometry:", err.Error())
+			}
+			if errors.Is(err, errTiled) && (pos.Maximised || pos.Fullscreen) {
+				// The compositor owns the geometry, but the state still applies
+				applyWindowState(rt, pos)
+				setLinuxWindowState(pos.Maximised && !pos.Fullscreen, pos.Fullscreen, dbg)
 			}
 			return
 		}
 		if dbg {
-			println("[WindowPos][DEBUG] Applied position using xdotool")
+			println("[WindowPos][DEBUG] Applied geometry")
 		}
 
-		// Apply size
-		if dbg {
-			println("[WindowPos][DEBUG] Applying size with xdotool windowsize", pos.Width, pos.Height)
-		}
-
-		cmd = exec.Command("xdotool", "search", "--name", "^SimpleAI", "windowsize",
-			strconv.Itoa(pos.Width), strconv.Itoa(pos.Height))
-		if err := cmd.Run(); err != nil {
+		// Maximised/fullscreen: the window manager owns the geometry from here on,
+		// so there is no position drift to correct
+		if pos.Maximised || pos.Fullscreen {
 			if dbg {
-				println("[WindowPos][DEBUG] ERROR: Failed to set size:", err.Error())
+				println("[WindowPos][DEBUG] Applying window state - Maximised:", pos.Maximised, "Fullscreen:", pos.Fullscreen)
 			}
+			applyWindowState(rt, pos)
+			setLinuxWindowState(pos.Maximised && !pos.Fullscreen, pos.Fullscreen, dbg)
 			return
 		}
-		if dbg {
-			println("[WindowPos][DEBUG] Applied size using xdotool")
-		}
 
-		// Monitor and re-apply position if window manager moves it
 		// GTK/WM may reposition the window after initial placement
-		const monitorAttempts = 20  // Monitor for 2 seconds (20 x 100ms)
-		const monitorInterval = 100 // milliseconds
+		wpm.correctDrift(backendWindow{rt}, pos, applied, checks, interval, dbg)
+	}()
+}
 
-		if dbg {
-			println("[WindowPos][DEBUG] Starting position monitoring for 2 seconds...")
-		}
+// Drift correction: GTK or the window manager may still move a window for a
+// moment after it was placed. The window is watched for driftChecks x
+// driftInterval (2 seconds) and moved back whenever it has drifted.
+var (
+	driftChecks   = 20
+	driftInterval = 100 * time.Millisecond
+)
 
-		for i := 0; i < monitorAttempts; i++ {
-			exec.Command("sleep", "0.1").Run()
+// correctDrift watches a placed window through rt and moves it back to pos
+// whenever its position differs. applied is the number of the placement; the
+// correction stops when another position is applied.
+func (wpm *WindowPositionManager) correctDrift(rt WindowRuntime, pos WindowPosition, applied, checks int, interval time.Duration, dbg bool) {
+	if dbg {
+		println("[WindowPos][DEBUG] Starting position monitoring for", (time.Duration(checks) * interval).String())
+	}
 
-			actualX, actualY, actualWidth, actualHeight, ok := getLinuxWindowGeometry(false) // Disable verbose logging in loop
-			if !ok {
-				continue
-			}
+	for i := 0; i < checks; i++ {
+		time.Sleep(interval)
 
-			if i == 0 && dbg {
-				println("[WindowPos][DEBUG] Initial verification - X:", actualX, "Y:", actualY, "W:", actualWidth, "H:", actualHeight)
+		wpm.mu.RLock()
+		superseded := wpm.applied != applied
+		wpm.mu.RUnlock()
+		if superseded {
+			if dbg {
+				println("[WindowPos][DEBUG] Another position was applied, monitoring stopped")
 			}
+			return
+		}
 
-			// Check if position drifted
-			if actualX != pos.X || actualY != pos.Y {
-				if dbg {
-					println("[WindowPos][DEBUG] ⚠ Position drift detected at", i*monitorInterval, "ms - Expected:", pos.X, pos.Y, "Got:", actualX, actualY, "ΔX:", actualX-pos.X, "ΔY:", actualY-pos.Y)
-					println("[WindowPos][DEBUG] Re-applying position...")
-				}
+		actualX, actualY := rt.Position()
+		if i == 0 && dbg {
+			println("[WindowPos][DEBUG] Initial verification - X:", actualX, "Y:", actualY)
+		}
 
-				// Re-apply position
-				cmd := exec.Command("xdotool", "search", "--name", "^SimpleAI", "windowmove",
-					strconv.Itoa(pos.X), strconv.Itoa(pos.Y))
-				if err := cmd.Run(); err != nil {
-					if dbg {
-						println("[WindowPos][DEBUG] Failed to re-apply position:", err.Error())
-					}
-				} else if dbg {
-					println("[WindowPos][DEBUG] Position re-applied")
-				}
-			} else if i == monitorAttempts-1 && dbg {
-				// Last check - position is stable
-				println("[WindowPos][DEBUG] ✓ Position stable at X:", actualX, "Y:", actualY, "after", i*monitorInterval, "ms")
+		// Check if position drifted
+		if actualX != pos.X || actualY != pos.Y {
+			if dbg {
+				println("[WindowPos][DEBUG] ⚠ Position drift detected after", (time.Duration(i) * interval).String(), "- Expected:", pos.X, pos.Y, "Got:", actualX, actualY, "ΔX:", actualX-pos.X, "ΔY:", actualY-pos.Y)
+				println("[WindowPos][DEBUG] Re-applying position...")
 			}
+			rt.SetPosition(pos.X, pos.Y)
+		} else if i == checks-1 && dbg {
+			// Last check - position is stable
+			println("[WindowPos][DEBUG] ✓ Position stable at X:", actualX, "Y:", actualY, "after", (time.Duration(i) * interval).String())
 		}
+	}
 
-		if dbg {
-			println("[WindowPos][DEBUG] Position monitoring complete")
-		}
-	}()
+	if dbg {
+		println("[WindowPos][DEBUG] Position monitoring complete")
+	}
 }
 
-// SavePosition saves current window position (Linux implementation)
-func (wpm *WindowPositionManager) SavePosition(ctx context.Context, windowID string, storagePath string) {
+// backendWindow is the Wails runtime with the position read and set through
+// the window backend, because GTK often reports (0, 0) and its moves may be
+// ignored by the window manager
+type backendWindow struct {
+	WindowRuntime
+}
+
+// Position falls back to the Wails runtime if the backend can't read the
+// geometry for a moment
+func (w backendWindow) Position() (x, y int) {
+	if x, y, _, _, ok := getLinuxWindowGeometry(false); ok {
+		return x, y
+	}
+	return w.WindowRuntime.Position()
+}
+
+func (w backendWindow) SetPosition(x, y int) {
+	moveResizeLinuxWindow(x11ConfigX|x11ConfigY, x, y, 0, 0)
+}
+
+// CurrentPosition returns the current window geometry (Linux implementation).
+// Falls back to the window backend when the Wails runtime reports (0, 0).
+// ok is false if no valid geometry could be read.
+func (wpm *WindowPositionManager) CurrentPosition(ctx context.Context) (pos WindowPosition, ok bool) {
 	const dbg = false // Set to true to enable detailed debug logging
 
 	defer func() {
 		if r := recover(); r != nil {
 			if dbg {
-				println("[WindowPos][DEBUG] Recovered from panic during save:", r)
+				println("[WindowPos][DEBUG] Recovered from panic while reading geometry:", r)
 			}
+			ok = false
 		}
 	}()
 
-	if dbg {
-		println("[WindowPos][DEBUG] SavePosition() called for", windowID)
+	rt := wpm.window(ctx)
+	native := isWailsRuntime(rt)
+
+	// Window state first: a minimised window has no usable geometry
+	readWindowState(rt, &pos)
+	if native {
+		if maximised, fullscreen, hidden, found := getLinuxWindowState(dbg); found {
+			// GTK doesn't always notice state changes made by the window manager
+			pos.Maximised = pos.Maximised || maximised
+			pos.Fullscreen = pos.Fullscreen || fullscreen
+			pos.Minimised = pos.Minimised || hidden
+		}
+		if desktop, found := getLinuxWindowDesktop(dbg); found {
+			pos.Desktop = &desktop
+		}
+	}
+	if pos.Minimised {
+		return withScreen(pos, rt.Screens()), true
 	}
 
-	// First, try Wails runtime methods
-	x, y := runtime.WindowGetPosition(ctx)
-	width, height := runtime.WindowGetSize(ctx)
+	// First, try the window runtime (GTK reports logical pixels)
+	physical := false
+	x, y := rt.Position()
+	width, height := rt.Size()
 
 	if dbg {
-		println("[WindowPos][DEBUG] Wails runtime returned - X:", x, "Y:", y, "W:", width, "H:", height)
+		println("[WindowPos][DEBUG] Window runtime returned - X:", x, "Y:", y, "W:", width, "H:", height)
 	}
 
 	// Check if we got default/invalid values (common GTK issue)
-	if x == 0 && y == 0 {
+	if native && x == 0 && y == 0 {
 		if dbg {
-			println("[WindowPos][DEBUG] Wails returned (0,0), trying xdotool fallback")
+			println("[WindowPos][DEBUG] Wails returned (0,0), trying window backend")
 		}
-		// Try xdotool as fallback
-		xX, xY, xWidth, xHeight, ok := getLinuxWindowGeometry(dbg)
-		if ok {
-			if dbg {
-				println("[WindowPos][DEBUG] xdotool success - X:", xX, "Y:", xY, "W:", xWidth, "H:", xHeight)
-			}
-			x, y, width, height = xX, xY, xWidth, xHeight
-		} else {
+		// Read the geometry through the window backend as fallback
+		xX, xY, xWidth, xHeight, found := getLinuxWindowGeometry(dbg)
+		if !found {
 			if dbg {
-				println("[WindowPos][DEBUG] xdotool failed - install xdotool for Linux position tracking")
-				println("[WindowPos][DEBUG] Debian/Ubuntu: sudo apt-get install xdotool")
-				println("[WindowPos][DEBUG] openSUSE/SUSE: sudo zypper install xdotool")
-				println("[WindowPos][DEBUG] Fedora/RHEL: sudo dnf install xdotool")
-				println("[WindowPos][DEBUG] Arch Linux: sudo pacman -S xdotool")
+				println("[WindowPos][DEBUG] Window backend failed - see WindowBackend() for the session's backend")
 			}
-			return
+			return WindowPosition{}, false
+		}
+		if dbg {
+			println("[WindowPos][DEBUG] Window backend success - X:", xX, "Y:", xY, "W:", xWidth, "H:", xHeight)
 		}
+		x, y, width, height = xX, xY, xWidth, xHeight
+		physical = linuxBackendPhysical()
 	}
 
-	// Don't save invalid dimensions
+	// Reject invalid dimensions
 	if width == 0 || height == 0 {
+		return WindowPosition{}, false
+	}
+	pos.X, pos.Y, pos.Width, pos.Height = x, y, width, height
+	pos = withScreen(pos, rt.Screens())
+	if physical {
+		pos = withScale(pos, rt)
+	}
+	return pos, true
+}
+
+// SavePosition saves current window position (Linux implementation)
+func (wpm *WindowPositionManager) SavePosition(ctx context.Context, windowID string, storagePath string) {
+	const dbg = false // Set to true to enable detailed debug logging
+
+	if dbg {
+		println("[WindowPos][DEBUG] SavePosition() called for", windowID)
+	}
+
+	pos, ok := wpm.CurrentPosition(ctx)
+	if !ok {
 		if dbg {
-			println("[WindowPos][DEBUG] Skipping save - invalid dimensions")
+			println("[WindowPos][DEBUG] Skipping save - no valid geometry")
 		}
 		return
 	}
 
 	if dbg {
-		println("[WindowPos][DEBUG] Saving position for", windowID, "- X:", x, "Y:", y, "W:", width, "H:", height)
+		println("[WindowPos][DEBUG] Saving position for", windowID, "- X:", pos.X, "Y:", pos.Y, "W:", pos.Width, "H:", pos.Height, "Maximised:", pos.Maximised, "Fullscreen:", pos.Fullscreen, "Minimised:", pos.Minimised)
 	}
 
-	// Reload from disk to preserve positions of other running instances
-	wpm.Load(storagePath)
-
-	wpm.mu.Lock()
-	wpm.positions[windowID] = &WindowPosition{
-		X:      x,
-		Y:      y,
-		Width:  width,
-		Height: height,
+	// Merge into the file in one transaction to preserve positions of other running instances
+	if err := wpm.saveMeasured(ctx, windowID, storagePath, pos); err != nil && !errors.Is(err, ErrNewerFormat) {
+		println("[WindowPos] Could not save position for", windowID, "-", err.Error())
 	}
-	wpm.mu.Unlock()
-
-	wpm.Save(storagePath)
 }
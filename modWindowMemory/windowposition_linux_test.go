@@ -0,0 +1,139 @@
+//go:build linux
+// +build linux
+
+package modWindowMemory
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// movingRuntime is a FakeRuntime whose window manager moves the window by an
+// offset the next moves times it is placed, like GTK or a window manager
+// placing a new window again
+type movingRuntime struct {
+	*FakeRuntime
+	mu    sync.Mutex
+	moves int
+	by    Rect // Offset of each move
+}
+
+func (m *movingRuntime) SetPosition(x, y int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.moves > 0 {
+		m.moves--
+		x, y = x+m.by.X, y+m.by.Y
+	}
+	m.FakeRuntime.SetPosition(x, y)
+}
+
+// fastDrift shortens the drift correction for the test
+func fastDrift(t *testing.T, checks int) {
+	savedChecks, savedInterval := driftChecks, driftInterval
+	driftChecks, driftInterval = checks, time.Millisecond
+	t.Cleanup(func() { driftChecks, driftInterval = savedChecks, savedInterval })
+}
+
+func TestCorrectDrift(t *testing.T) {
+	screens := []Rect{{Width: 1920, Height: 1080}}
+	pos := WindowPosition{X: 100, Y: 100, Width: 800, Height: 600}
+	tests := []struct {
+		name  string
+		moves int
+		calls int // SetPosition calls to move it back
+	}{
+		{name: "stays in place", moves: 0, calls: 0},
+		{name: "moved once", moves: 1, calls: 1},
+		{name: "moved three times", moves: 3, calls: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Placed at pos, then moved by the window manager
+			fake := &FakeRuntime{ScreenList: screens, Window: pos}
+			rt := &movingRuntime{FakeRuntime: fake, by: Rect{X: 10, Y: 28}}
+			if tt.moves > 0 {
+				fake.Window.X, fake.Window.Y = pos.X+rt.by.X, pos.Y+rt.by.Y
+				rt.moves = tt.moves - 1
+			}
+
+			wpm := NewWindowPositionManager()
+			wpm.correctDrift(rt, pos, wpm.applied, 5, time.Millisecond, false)
+
+			if got := fake.Snapshot().bounds(); got.X != pos.X || got.Y != pos.Y {
+				t.Errorf("window at (%d, %d), want (%d, %d)", got.X, got.Y, pos.X, pos.Y)
+			}
+			if calls := fake.SetPositionCalls; calls != tt.calls {
+				t.Errorf("%d SetPosition calls, want %d", calls, tt.calls)
+			}
+		})
+	}
+}
+
+// TestApplyPositionDrift checks that ApplyPosition corrects drift through an
+// injected runtime, and that a newer position stops the old correction
+func TestApplyPositionDrift(t *testing.T) {
+	fastDrift(t, 50)
+	ctx := context.Background()
+
+	fake := &FakeRuntime{ScreenList: []Rect{{Width: 1920, Height: 1080}}}
+	rt := &movingRuntime{FakeRuntime: fake, moves: 1, by: Rect{X: 10, Y: 28}}
+	wpm := NewWindowPositionManager()
+	wpm.SetRuntime(rt)
+
+	first := WindowPosition{X: 100, Y: 100, Width: 800, Height: 600}
+	wpm.ApplyPosition(ctx, "test", first)
+	waitForPosition(t, fake, first.bounds())
+
+	second := WindowPosition{X: 900, Y: 300, Width: 800, Height: 600}
+	wpm.ApplyPosition(ctx, "test", second)
+	time.Sleep(time.Duration(driftChecks) * driftInterval * 2)
+	if got := fake.Snapshot().bounds(); got != second.bounds() {
+		t.Errorf("window at %v after a newer position was applied, want %v", got, second.bounds())
+	}
+
+	// Both placements and one correction; the first correction must not
+	// have moved the window back to the first position
+	fake.mu.Lock()
+	calls := fake.SetPositionCalls
+	fake.mu.Unlock()
+	if calls != 3 {
+		t.Errorf("%d SetPosition calls, want 3", calls)
+	}
+}
+
+// waitForPosition waits until the drift correction has moved the window to want
+func waitForPosition(t *testing.T, fake *FakeRuntime, want Rect) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for fake.Snapshot().bounds() != want {
+		if time.Now().After(deadline) {
+			t.Fatalf("window at %v, want %v", fake.Snapshot().bounds(), want)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// TestApplyPositionLogical checks that geometry in logical pixels, like that
+// of the Wails runtime, isn't converted when the screen's scale differs
+func TestApplyPositionLogical(t *testing.T) {
+	fastDrift(t, 1)
+	normal := Rect{X: 0, Y: 0, Width: 1920, Height: 1080}
+	hidpi := Rect{X: 1920, Y: 0, Width: 1920, Height: 1080}
+	fake := &FakeRuntime{ScreenList: []Rect{normal, hidpi}, Scales: []float64{1, 2}}
+	wpm := NewWindowPositionManager()
+	wpm.SetRuntime(fake)
+
+	want := WindowPosition{X: 2020, Y: 100, Width: 1024, Height: 768, Screen: &hidpi, Scale: 1}
+	wpm.ApplyPosition(context.Background(), "test", want)
+	if got := fake.Snapshot(); got.bounds() != want.bounds() {
+		t.Errorf("window at %v, want %v unchanged", got.bounds(), want.bounds())
+	}
+
+	pos, ok := wpm.CurrentPosition(context.Background())
+	if !ok || pos.Scale != 0 {
+		t.Errorf("logical geometry saved with scale %v (ok %v), want none", pos.Scale, ok)
+	}
+}
@@ -0,0 +1,120 @@
+package modWindowMemory
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+// TestDisplayEntries saves a window in two display configurations and checks
+// that each configuration gets its own position back
+func TestDisplayEntries(t *testing.T) {
+	laptop := []Rect{{Width: 1920, Height: 1080}}
+	docked := []Rect{{Width: 1920, Height: 1080}, {X: 1920, Width: 2560, Height: 1440}}
+	projector := []Rect{{Width: 1280, Height: 720}}
+
+	onLaptop := Rect{X: 100, Y: 100, Width: 800, Height: 600}
+	onDocked := Rect{X: 2500, Y: 200, Width: 1200, Height: 900}
+
+	ctx := context.Background()
+	wpm := NewWindowPositionManager()
+	save := func(screens []Rect, window Rect) {
+		t.Helper()
+		fake := &FakeRuntime{ScreenList: screens}
+		fake.Window.X, fake.Window.Y, fake.Window.Width, fake.Window.Height = window.X, window.Y, window.Width, window.Height
+		wpm.SetRuntime(fake)
+		pos, ok := wpm.CurrentPosition(ctx)
+		if !ok || !wpm.storeCurrentPosition("test", pos) {
+			t.Fatalf("position on %v not stored", screens)
+		}
+	}
+	save(laptop, onLaptop)
+	save(docked, onDocked)
+
+	saved := wpm.GetPosition("test")
+	if len(saved.Displays) != 2 {
+		t.Fatalf("saved %d display entries, want 2: %v", len(saved.Displays), saved.Displays)
+	}
+
+	tests := []struct {
+		name    string
+		screens []Rect
+		want    Rect
+	}{
+		{name: "laptop", screens: laptop, want: onLaptop},
+		{name: "docked", screens: docked, want: onDocked},
+		// Unknown configuration: the last saved position, moved onto the screen
+		{name: "projector", screens: projector, want: Rect{X: 80, Y: 0, Width: 1200, Height: 720}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &FakeRuntime{ScreenList: tt.screens}
+			wpm.SetRuntime(fake)
+			wpm.ApplyPosition(ctx, "test", *saved)
+			if got := fake.Snapshot().bounds(); got != tt.want {
+				t.Errorf("window at %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestUpdate checks that an update works on the file's positions, not on
+// the manager's: positions another instance removed don't come back
+func TestUpdate(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "windows.json")
+
+	first := NewWindowPositionManager()
+	first.SetPosition("a", 10, 10, 800, 600)
+	first.SetPosition("b", 20, 20, 800, 600)
+	if err := first.Save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	// Another instance removes a and adds c
+	second := NewWindowPositionManager()
+	err := second.Update(ctx, path, func() error {
+		second.RemovePosition("a")
+		second.SetPosition("c", 30, 30, 800, 600)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The first instance saves its own window; its copy of a is dropped
+	err = first.Update(ctx, path, func() error {
+		first.SetPosition("b", 40, 40, 1024, 768)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	saved := NewWindowPositionManager()
+	if err := saved.Load(path); err != nil {
+		t.Fatal(err)
+	}
+	if saved.GetPosition("a") != nil {
+		t.Error("removed position a came back")
+	}
+	if pos := saved.GetPosition("b"); pos == nil || pos.X != 40 {
+		t.Errorf("position b = %+v, want X 40", pos)
+	}
+	if saved.GetPosition("c") == nil {
+		t.Error("position c of the other instance was lost")
+	}
+
+	// ErrNoChange writes nothing
+	err = first.Update(ctx, path, func() error {
+		first.ClearPositions()
+		return ErrNoChange
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	unchanged := NewWindowPositionManager()
+	if err := unchanged.Load(path); err != nil || unchanged.GetPosition("b") == nil {
+		t.Errorf("positions changed by an update without changes (%v)", err)
+	}
+}
@@ -5,18 +5,18 @@ package modWindowMemory
 
 import (
 	"context"
-
-	"github.com/wailsapp/wails/v2/pkg/runtime"
+	"errors"
 )
 
 // Windows-specific window position management
 //
-// Windows has specific behavior with window decorations:
-// - WindowGetPosition returns coordinates INCLUDING titlebar and borders
-// - WindowSetPosition expects coordinates EXCLUDING decorations
-// This creates an offset that must be detected and compensated for.
+// Geometry is kept in physical pixels of the virtual screen. Wails sets
+// positions relative to the work area of the window's monitor and reports
+// sizes in DIP; the Wails runtime converts both (desktopWindow, desktop.go).
 //
-// This implementation:
+// A window may still not end up exactly where it was put, e.g. when a
+// monitor's work area changes while the window is moved. This
+// implementation:
 // 1. Detects the offset on first restore by comparing set vs. get coordinates
 // 2. Stores the offset in the manager for subsequent operations
 // 3. Compensates automatically on all position sets
@@ -29,77 +29,101 @@ func (wpm *WindowPositionManager) RestorePosition(ctx context.Context, windowID
 
 	if !exists || pos == nil || pos.Width == 0 || pos.Height == 0 {
 		println("[WindowPos] No saved position for", windowID)
+		if placed, ok := wpm.newWindowPosition(ctx); ok {
+			wpm.applyPosition(ctx, placed)
+		}
 		return
 	}
 
 	println("[WindowPos] Restoring position for", windowID, "- X:", pos.X, "Y:", pos.Y, "W:", pos.Width, "H:", pos.Height)
+	wpm.applyPosition(ctx, *pos)
+}
 
-	// Get screen dimensions to validate position
-	screens, err := runtime.ScreenGetAll(ctx)
-	if err == nil && len(screens) > 0 {
-		// Use primary screen dimensions
-		screenWidth := screens[0].Width
-		screenHeight := screens[0].Height
-
-		// Validate and correct position to stay within screen bounds
-		pos.X, pos.Y, pos.Width, pos.Height = validateAndCorrectPosition(pos.X, pos.Y, pos.Width, pos.Height, screenWidth, screenHeight)
-	} else {
-		println("[WindowPos] Warning: Could not get screen dimensions, skipping bounds validation")
-	}
+// WindowBackend returns the name of the backend that reads and moves windows
+// (Windows implementation): always "win32", the Win32 API is always available
+func WindowBackend() (string, error) {
+	return "win32", nil
+}
+
+// ApplyPosition moves the window of a running application to pos (Windows implementation)
+func (wpm *WindowPositionManager) ApplyPosition(ctx context.Context, windowID string, pos WindowPosition) {
+	println("[WindowPos] Applying position for", windowID, "- X:", pos.X, "Y:", pos.Y, "W:", pos.Width, "H:", pos.Height)
+	wpm.applyPosition(ctx, pos)
+}
+
+// applyPosition validates pos against the screen and sets it with offset compensation
+func (wpm *WindowPositionManager) applyPosition(ctx context.Context, pos WindowPosition) {
+	rt := wpm.window(ctx)
+
+	// Keep the window on its saved screen (or the nearest one) and fully visible
+	screens := rt.Screens()
+	pos = placeScaled(pos.forDisplays(screens), screens, rt)
+
+	// Bounds can only be set on a normal window
+	resetWindowState(rt)
 
 	// Apply offset compensation (discovered on previous run)
 	targetX := pos.X - wpm.xOffset
 	targetY := pos.Y - wpm.yOffset
-	runtime.WindowSetPosition(ctx, targetX, targetY)
-	runtime.WindowSetSize(ctx, pos.Width, pos.Height)
+	rt.SetPosition(targetX, targetY)
+	rt.SetSize(pos.Width, pos.Height)
 
 	// Measure actual offset and re-apply if needed (first run or offset changed)
-	actualX, actualY := runtime.WindowGetPosition(ctx)
+	actualX, actualY := rt.Position()
 	offsetX := actualX - targetX
 	offsetY := actualY - targetY
 
-	if offsetX != 0 || offsetY != 0 {
+	if offsetX != wpm.xOffset || offsetY != wpm.yOffset {
 		println("[WindowPos] Detected offset - X:", offsetX, "Y:", offsetY, "- Compensating immediately")
 		wpm.mu.Lock()
 		wpm.xOffset = offsetX
 		wpm.yOffset = offsetY
 		wpm.mu.Unlock()
 		// Re-apply with compensation
-		runtime.WindowSetPosition(ctx, pos.X-wpm.xOffset, pos.Y-wpm.yOffset)
+		rt.SetPosition(pos.X-wpm.xOffset, pos.Y-wpm.yOffset)
 	}
+
+	applyWindowState(rt, pos)
 }
 
-// SavePosition saves current window position (Windows implementation)
-func (wpm *WindowPositionManager) SavePosition(ctx context.Context, windowID string, storagePath string) {
+// CurrentPosition returns the current window geometry (Windows implementation).
+// ok is false if the window has no valid size (e.g. during shutdown).
+func (wpm *WindowPositionManager) CurrentPosition(ctx context.Context) (pos WindowPosition, ok bool) {
 	defer func() {
 		if r := recover(); r != nil {
 			// Ignore panics during shutdown - window may be destroyed
-			println("[WindowPos] Recovered from panic during save:", r)
+			println("[WindowPos] Recovered from panic while reading geometry:", r)
+			ok = false
 		}
 	}()
 
-	x, y := runtime.WindowGetPosition(ctx)
-	width, height := runtime.WindowGetSize(ctx)
+	rt := wpm.window(ctx)
+	x, y := rt.Position()
+	width, height := rt.Size()
+	pos = WindowPosition{X: x, Y: y, Width: width, Height: height}
+	readWindowState(rt, &pos)
+
+	// Minimised windows report no usable size, their bounds come from the last save
+	if !pos.Minimised && (width == 0 || height == 0) {
+		return WindowPosition{}, false
+	}
+	return withScale(withScreen(pos, rt.Screens()), rt), true
+}
+
+// SavePosition saves current window position (Windows implementation)
+func (wpm *WindowPositionManager) SavePosition(ctx context.Context, windowID string, storagePath string) {
+	pos, ok := wpm.CurrentPosition(ctx)
 
 	// Don't save invalid dimensions (happens during shutdown)
-	if width == 0 || height == 0 {
+	if !ok {
 		println("[WindowPos] Skipping save - invalid dimensions")
 		return
 	}
 
-	println("[WindowPos] Saving position for", windowID, "- X:", x, "Y:", y, "W:", width, "H:", height)
+	println("[WindowPos] Saving position for", windowID, "- X:", pos.X, "Y:", pos.Y, "W:", pos.Width, "H:", pos.Height, "Maximised:", pos.Maximised, "Fullscreen:", pos.Fullscreen, "Minimised:", pos.Minimised)
 
-	// Reload from disk to preserve positions of other running instances
-	wpm.Load(storagePath)
-
-	wpm.mu.Lock()
-	wpm.positions[windowID] = &WindowPosition{
-		X:      x,
-		Y:      y,
-		Width:  width,
-		Height: height,
+	// Merge into the file in one transaction to preserve positions of other running instances
+	if err := wpm.saveMeasured(ctx, windowID, storagePath, pos); err != nil && !errors.Is(err, ErrNewerFormat) {
+		println("[WindowPos] Could not save position for", windowID, "-", err.Error())
 	}
-	wpm.mu.Unlock()
-
-	wpm.Save(storagePath)
 }
@@ -0,0 +1,51 @@
+//go:build windows
+// +build windows
+
+package modWindowMemory
+
+import (
+	"context"
+	"testing"
+)
+
+// TestOffsetRoundTrip checks the decoration offset compensation: a position
+// read back from the window is the one that was applied
+func TestOffsetRoundTrip(t *testing.T) {
+	tests := []struct {
+		name             string
+		offsetX, offsetY int
+	}{
+		{name: "no decorations"},
+		{name: "title bar and borders", offsetX: 8, offsetY: 31},
+		{name: "negative offset", offsetX: -7, offsetY: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			fake := &FakeRuntime{ScreenList: []Rect{{Width: 1920, Height: 1080}}, PositionOffsetX: tt.offsetX, PositionOffsetY: tt.offsetY}
+			wpm := NewWindowPositionManager()
+			wpm.SetRuntime(fake)
+
+			want := WindowPosition{X: 300, Y: 200, Width: 800, Height: 600}
+			for i := 0; i < 2; i++ {
+				wpm.ApplyPosition(ctx, "test", want)
+				got, ok := wpm.CurrentPosition(ctx)
+				if !ok || got.bounds() != want.bounds() {
+					t.Fatalf("apply %d: read back %v (ok %v), want %v", i+1, got.bounds(), ok, want.bounds())
+				}
+			}
+			if wpm.xOffset != tt.offsetX || wpm.yOffset != tt.offsetY {
+				t.Errorf("offset (%d, %d), want (%d, %d)", wpm.xOffset, wpm.yOffset, tt.offsetX, tt.offsetY)
+			}
+
+			// Measured on the first apply, compensated right away on the second
+			calls := 2
+			if tt.offsetX != 0 || tt.offsetY != 0 {
+				calls = 3
+			}
+			if fake.SetPositionCalls != calls {
+				t.Errorf("%d SetPosition calls, want %d", fake.SetPositionCalls, calls)
+			}
+		})
+	}
+}
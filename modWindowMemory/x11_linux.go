@@ -0,0 +1,605 @@
+//go:build linux
+// +build linux
+
+package modWindowMemory
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Minimal X11 client
+//
+// Just enough of the X11 core protocol to read and set window geometry and to
+// talk EWMH with the window manager (see ewmh_linux.go), without cgo or
+// external tools. It connects to $DISPLAY like Xlib does: the local Unix socket
+// /tmp/.X11-unix/X<n> or TCP port 6000+<n>, authenticated with the
+// MIT-MAGIC-COOKIE-1 from $XAUTHORITY (or ~/.Xauthority) if there is one.
+//
+// Requests are sent one at a time and answered synchronously, which keeps the
+// client small; window memory only needs a handful of round trips per save.
+// A plain Xvfb server is enough to try it out on a headless machine:
+//
+//	Xvfb :99 & DISPLAY=:99 ./SimpleAI
+
+// X11 core protocol opcodes used by this client
+const (
+	x11OpGetWindowAttributes  = 3
+	x11OpMapWindow            = 8
+	x11OpConfigureWindow      = 12
+	x11OpGetGeometry          = 14
+	x11OpQueryTree            = 15
+	x11OpInternAtom           = 16
+	x11OpGetProperty          = 20
+	x11OpSendEvent            = 25
+	x11OpTranslateCoordinates = 40
+	x11OpSetInputFocus        = 42
+	x11OpQueryExtension       = 98
+)
+
+// RandR extension requests (minor opcodes). GetMonitors needs RandR 1.5.
+const (
+	randrQueryVersion = 0
+	randrGetMonitors  = 42
+)
+
+// ConfigureWindow value mask bits
+const (
+	x11ConfigX         = 1 << 0
+	x11ConfigY         = 1 << 1
+	x11ConfigWidth     = 1 << 2
+	x11ConfigHeight    = 1 << 3
+	x11ConfigStackMode = 1 << 6
+)
+
+// x11Timeout bounds every round trip, so a hung server can't block a save
+const x11Timeout = 2 * time.Second
+
+// x11Error is an error reply of the server, e.g. BadWindow for a window that
+// was destroyed in the meantime. The connection stays usable.
+type x11Error struct {
+	code   byte
+	opcode byte
+}
+
+func (e *x11Error) Error() string {
+	return fmt.Sprintf("X11 error %d in request %d", e.code, e.opcode)
+}
+
+// x11Conn is a connection to an X server. Safe for concurrent use.
+type x11Conn struct {
+	mu    sync.Mutex
+	conn  net.Conn
+	seq   uint16            // Sequence number of the last request sent
+	root  uint32            // Root window of the default screen
+	ids   uint32            // Resource ID base, for windows created by this client
+	atoms map[string]uint32 // Interned atoms by name
+
+	randrOnce sync.Once
+	randr     byte // Major opcode of RandR 1.5, 0 if the server doesn't have it
+}
+
+// dialX11 connects to the X server of a display name like ":0", ":99.0" or
+// "host:1". An empty name means $DISPLAY.
+func dialX11(display string) (*x11Conn, error) {
+	if display == "" {
+		display = os.Getenv("DISPLAY")
+	}
+	if display == "" {
+		return nil, errors.New("DISPLAY is not set")
+	}
+	host, number, screen, err := parseDisplay(display)
+	if err != nil {
+		return nil, err
+	}
+
+	var conn net.Conn
+	if host == "" || host == "unix" {
+		socket := "/tmp/.X11-unix/X" + number
+		conn, err = net.DialTimeout("unix", socket, x11Timeout)
+		if err != nil {
+			// Some servers only listen on the abstract socket
+			conn, err = net.DialTimeout("unix", "@"+socket, x11Timeout)
+		}
+	} else {
+		port, _ := strconv.Atoi(number)
+		conn, err = net.DialTimeout("tcp", net.JoinHostPort(host, strconv.Itoa(6000+port)), x11Timeout)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("connecting to display %s: %w", display, err)
+	}
+
+	c := &x11Conn{conn: conn, atoms: make(map[string]uint32)}
+	if err := c.handshake(host, number, screen); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("display %s: %w", display, err)
+	}
+	return c, nil
+}
+
+// parseDisplay splits "[host]:number[.screen]"
+func parseDisplay(display string) (host, number string, screen int, err error) {
+	colon := strings.LastIndex(display, ":")
+	if colon < 0 {
+		return "", "", 0, fmt.Errorf("invalid display name %q", display)
+	}
+	host, number = display[:colon], display[colon+1:]
+	if dot := strings.Index(number, "."); dot >= 0 {
+		if screen, err = strconv.Atoi(number[dot+1:]); err != nil {
+			return "", "", 0, fmt.Errorf("invalid display name %q", display)
+		}
+		number = number[:dot]
+	}
+	if _, err := strconv.Atoi(number); err != nil {
+		return "", "", 0, fmt.Errorf("invalid display name %q", display)
+	}
+	return host, number, screen, nil
+}
+
+// handshake sends the connection setup and finds the root window of a screen
+func (c *x11Conn) handshake(host, number string, screen int) error {
+	authName, authData := xauthCookie(host, number)
+
+	var setup bytes.Buffer
+	setup.WriteByte('l') // Little-endian
+	setup.WriteByte(0)
+	writeUint16(&setup, 11) // Protocol 11.0
+	writeUint16(&setup, 0)
+	writeUint16(&setup, uint16(len(authName)))
+	writeUint16(&setup, uint16(len(authData)))
+	writeUint16(&setup, 0)
+	setup.Write(pad4(authName))
+	setup.Write(pad4(authData))
+
+	c.conn.SetDeadline(time.Now().Add(x11Timeout))
+	defer c.conn.SetDeadline(time.Time{})
+	if _, err := c.conn.Write(setup.Bytes()); err != nil {
+		return err
+	}
+
+	header := make([]byte, 8)
+	if _, err := io.ReadFull(c.conn, header); err != nil {
+		return err
+	}
+	info := make([]byte, int(binary.LittleEndian.Uint16(header[6:]))*4)
+	if _, err := io.ReadFull(c.conn, info); err != nil {
+		return err
+	}
+	switch header[0] {
+	case 1: // Success
+	case 0: // Failed
+		return fmt.Errorf("connection refused: %s", strings.TrimSpace(string(info[:min(int(header[1]), len(info))])))
+	default: // Authenticate
+		return fmt.Errorf("authentication required: %s", strings.TrimSpace(string(bytes.TrimRight(info, "\x00"))))
+	}
+
+	// Fixed part, vendor string and pixmap formats come before the screens
+	if len(info) < 32 {
+		return errors.New("short connection setup reply")
+	}
+	c.ids = binary.LittleEndian.Uint32(info[4:])
+	vendorLength := int(binary.LittleEndian.Uint16(info[16:]))
+	screens := int(info[20])
+	formats := int(info[21])
+	offset := 32 + len(pad4(make([]byte, vendorLength))) + 8*formats
+	if screen >= screens {
+		return fmt.Errorf("screen %d does not exist", screen)
+	}
+	for i := 0; ; i++ {
+		if offset+40 > len(info) {
+			return errors.New("short connection setup reply")
+		}
+		if i == screen {
+			c.root = binary.LittleEndian.Uint32(info[offset:])
+			return nil
+		}
+		// Skip the screen's depths and their visuals
+		depths := int(info[offset+39])
+		offset += 40
+		for d := 0; d < depths; d++ {
+			if offset+8 > len(info) {
+				return errors.New("short connection setup reply")
+			}
+			visuals := int(binary.LittleEndian.Uint16(info[offset+2:]))
+			offset += 8 + 24*visuals
+		}
+	}
+}
+
+// xauthCookie finds the MIT-MAGIC-COOKIE-1 for a display in the Xauthority
+// file. Returns nothing if there is none; servers without access control
+// (like a plain Xvfb) accept that.
+func xauthCookie(host, number string) (name, data []byte) {
+	path := os.Getenv("XAUTHORITY")
+	if path == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, nil
+		}
+		path = filepath.Join(home, ".Xauthority")
+	}
+	file, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil
+	}
+
+	const familyLocal = 256
+	const familyWild = 65535
+	hostname, _ := os.Hostname()
+	local := host == "" || host == "unix" || host == hostname
+
+	r := bytes.NewReader(file)
+	for {
+		var family uint16
+		if binary.Read(r, binary.BigEndian, &family) != nil {
+			return nil, nil
+		}
+		address, ok1 := readCounted(r)
+		display, ok2 := readCounted(r)
+		authName, ok3 := readCounted(r)
+		authData, ok4 := readCounted(r)
+		if !ok1 || !ok2 || !ok3 || !ok4 {
+			return nil, nil
+		}
+
+		hostMatches := family == familyWild ||
+			(family == familyLocal && local && string(address) == hostname) ||
+			(!local && string(address) == host)
+		if hostMatches && (len(display) == 0 || string(display) == number) &&
+			string(authName) == "MIT-MAGIC-COOKIE-1" {
+			return authName, authData
+		}
+	}
+}
+
+// readCounted reads a big-endian length-prefixed field of an Xauthority entry
+func readCounted(r *bytes.Reader) ([]byte, bool) {
+	var length uint16
+	if binary.Read(r, binary.BigEndian, &length) != nil {
+		return nil, false
+	}
+	field := make([]byte, length)
+	if _, err := io.ReadFull(r, field); err != nil {
+		return nil, false
+	}
+	return field, true
+}
+
+// Close closes the connection
+func (c *x11Conn) Close() error {
+	return c.conn.Close()
+}
+
+// request sends a request and, if wantReply is set, waits for its reply.
+// body is everything after the 4-byte request header and is padded here.
+// Errors of requests without reply are reported by the server later and skipped.
+func (c *x11Conn) request(opcode, data byte, body []byte, wantReply bool) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	body = pad4(body)
+	message := make([]byte, 4, 4+len(body))
+	message[0] = opcode
+	message[1] = data
+	binary.LittleEndian.PutUint16(message[2:], uint16(1+len(body)/4))
+	message = append(message, body...)
+
+	c.conn.SetDeadline(time.Now().Add(x11Timeout))
+	defer c.conn.SetDeadline(time.Time{})
+	if _, err := c.conn.Write(message); err != nil {
+		return nil, err
+	}
+	c.seq++
+	if !wantReply {
+		return nil, nil
+	}
+	return c.readReply(c.seq)
+}
+
+// readReply reads until the reply or error of a request arrives.
+// Events and answers to earlier requests are skipped.
+func (c *x11Conn) readReply(seq uint16) ([]byte, error) {
+	for {
+		header := make([]byte, 32)
+		if _, err := io.ReadFull(c.conn, header); err != nil {
+			return nil, err
+		}
+		switch header[0] {
+		case 0: // Error
+			if binary.LittleEndian.Uint16(header[2:]) == seq {
+				return nil, &x11Error{code: header[1], opcode: header[10]}
+			}
+		case 1: // Reply
+			extra := int(binary.LittleEndian.Uint32(header[4:])) * 4
+			reply := make([]byte, 32+extra)
+			copy(reply, header)
+			if _, err := io.ReadFull(c.conn, reply[32:]); err != nil {
+				return nil, err
+			}
+			if binary.LittleEndian.Uint16(header[2:]) == seq {
+				return reply, nil
+			}
+		default: // Event - none are selected, but the server may send some anyway
+		}
+	}
+}
+
+// atom returns the atom of a name, interning it on the server if needed
+func (c *x11Conn) atom(name string) (uint32, error) {
+	c.mu.Lock()
+	atom, ok := c.atoms[name]
+	c.mu.Unlock()
+	if ok {
+		return atom, nil
+	}
+
+	var body bytes.Buffer
+	writeUint16(&body, uint16(len(name)))
+	writeUint16(&body, 0)
+	body.WriteString(name)
+	reply, err := c.request(x11OpInternAtom, 0, body.Bytes(), true)
+	if err != nil {
+		return 0, err
+	}
+	atom = binary.LittleEndian.Uint32(reply[8:])
+
+	c.mu.Lock()
+	c.atoms[name] = atom
+	c.mu.Unlock()
+	return atom, nil
+}
+
+// property reads a window property of any type. Returns nil if it isn't set.
+// format is the size of one item in bits (8, 16 or 32).
+func (c *x11Conn) property(window uint32, name string) (value []byte, format byte, err error) {
+	atom, err := c.atom(name)
+	if err != nil {
+		return nil, 0, err
+	}
+	var body bytes.Buffer
+	writeUint32(&body, window)
+	writeUint32(&body, atom)
+	writeUint32(&body, 0)       // AnyPropertyType
+	writeUint32(&body, 0)       // Offset
+	writeUint32(&body, 1<<16-1) // Length in 32-bit units
+	reply, err := c.request(x11OpGetProperty, 0, body.Bytes(), true)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	format = reply[1]
+	items := int(binary.LittleEndian.Uint32(reply[16:]))
+	size := items * int(format) / 8
+	if format == 0 || 32+size > len(reply) {
+		return nil, 0, nil
+	}
+	return reply[32 : 32+size], format, nil
+}
+
+// cardinals reads a property of 32-bit values (CARDINAL, WINDOW, ATOM)
+func (c *x11Conn) cardinals(window uint32, name string) ([]uint32, error) {
+	value, format, err := c.property(window, name)
+	if err != nil || format != 32 {
+		return nil, err
+	}
+	values := make([]uint32, len(value)/4)
+	for i := range values {
+		values[i] = binary.LittleEndian.Uint32(value[i*4:])
+	}
+	return values, nil
+}
+
+// children returns the child windows of a window, bottom to top
+func (c *x11Conn) children(window uint32) ([]uint32, error) {
+	var body bytes.Buffer
+	writeUint32(&body, window)
+	reply, err := c.request(x11OpQueryTree, 0, body.Bytes(), true)
+	if err != nil {
+		return nil, err
+	}
+	count := int(binary.LittleEndian.Uint16(reply[16:]))
+	if 32+count*4 > len(reply) {
+		return nil, errors.New("short QueryTree reply")
+	}
+	children := make([]uint32, count)
+	for i := range children {
+		children[i] = binary.LittleEndian.Uint32(reply[32+i*4:])
+	}
+	return children, nil
+}
+
+// viewable reports whether a window and all its ancestors are mapped
+func (c *x11Conn) viewable(window uint32) (bool, error) {
+	const mapStateViewable = 2
+	var body bytes.Buffer
+	writeUint32(&body, window)
+	reply, err := c.request(x11OpGetWindowAttributes, 0, body.Bytes(), true)
+	if err != nil {
+		return false, err
+	}
+	return reply[26] == mapStateViewable, nil
+}
+
+// geometry returns the position of a window's client area relative to the
+// root window, and its size. The position is the same xdotool reports, without
+// the frame the window manager draws around it.
+func (c *x11Conn) geometry(window uint32) (x, y, width, height int, err error) {
+	var body bytes.Buffer
+	writeUint32(&body, window)
+	reply, err := c.request(x11OpGetGeometry, 0, body.Bytes(), true)
+	if err != nil {
+		return 0, 0, 0, 0, err
+	}
+	width = int(binary.LittleEndian.Uint16(reply[16:]))
+	height = int(binary.LittleEndian.Uint16(reply[18:]))
+
+	// Window managers reparent windows into frames, so the geometry's position
+	// is relative to the frame; translate it to root coordinates instead
+	body.Reset()
+	writeUint32(&body, window)
+	writeUint32(&body, c.root)
+	writeUint32(&body, 0) // Source x and y
+	reply, err = c.request(x11OpTranslateCoordinates, 0, body.Bytes(), true)
+	if err != nil {
+		return 0, 0, 0, 0, err
+	}
+	x = int(int16(binary.LittleEndian.Uint16(reply[12:])))
+	y = int(int16(binary.LittleEndian.Uint16(reply[14:])))
+	return x, y, width, height, nil
+}
+
+// configure sends ConfigureWindow with values in mask order (x, y, width,
+// height, ..., stack mode). Without a window manager this moves the window
+// directly; with one it becomes a request the window manager may adjust.
+func (c *x11Conn) configure(window uint32, mask uint16, values ...uint32) error {
+	var body bytes.Buffer
+	writeUint32(&body, window)
+	writeUint16(&body, mask)
+	writeUint16(&body, 0)
+	for _, value := range values {
+		writeUint32(&body, value)
+	}
+	_, err := c.request(x11OpConfigureWindow, 0, body.Bytes(), false)
+	return err
+}
+
+// clientMessage sends a 32-bit ClientMessage about window to the root window,
+// which is how EWMH requests reach the window manager
+func (c *x11Conn) clientMessage(window uint32, messageType string, data ...uint32) error {
+	atom, err := c.atom(messageType)
+	if err != nil {
+		return err
+	}
+	const substructureNotify = 1 << 19
+	const substructureRedirect = 1 << 20
+
+	var body bytes.Buffer
+	writeUint32(&body, c.root)
+	writeUint32(&body, substructureNotify|substructureRedirect)
+	body.WriteByte(33) // ClientMessage event
+	body.WriteByte(32) // Format
+	writeUint16(&body, 0)
+	writeUint32(&body, window)
+	writeUint32(&body, atom)
+	for i := 0; i < 5; i++ {
+		var value uint32
+		if i < len(data) {
+			value = data[i]
+		}
+		writeUint32(&body, value)
+	}
+	_, err = c.request(x11OpSendEvent, 0, body.Bytes(), false)
+	return err
+}
+
+// mapWindow makes a window visible
+func (c *x11Conn) mapWindow(window uint32) error {
+	var body bytes.Buffer
+	writeUint32(&body, window)
+	_, err := c.request(x11OpMapWindow, 0, body.Bytes(), false)
+	return err
+}
+
+// setInputFocus gives the keyboard focus to a window
+func (c *x11Conn) setInputFocus(window uint32) error {
+	const revertToParent = 2
+	var body bytes.Buffer
+	writeUint32(&body, window)
+	writeUint32(&body, 0) // CurrentTime
+	_, err := c.request(x11OpSetInputFocus, revertToParent, body.Bytes(), false)
+	return err
+}
+
+// randrOpcode returns the major opcode of the RandR extension, or 0 if the
+// server doesn't support RandR 1.5. Checked once per connection.
+func (c *x11Conn) randrOpcode() byte {
+	c.randrOnce.Do(func() {
+		const name = "RANDR"
+		var body bytes.Buffer
+		writeUint16(&body, uint16(len(name)))
+		writeUint16(&body, 0)
+		body.WriteString(name)
+		reply, err := c.request(x11OpQueryExtension, 0, body.Bytes(), true)
+		if err != nil || reply[8] == 0 {
+			return
+		}
+		opcode := reply[9]
+
+		// The server only answers newer requests after the client announced its version
+		var version bytes.Buffer
+		writeUint32(&version, 1)
+		writeUint32(&version, 5)
+		reply, err = c.request(opcode, randrQueryVersion, version.Bytes(), true)
+		if err != nil {
+			return
+		}
+		major, minor := binary.LittleEndian.Uint32(reply[8:]), binary.LittleEndian.Uint32(reply[12:])
+		if major > 1 || (major == 1 && minor >= 5) {
+			c.randr = opcode
+		}
+	})
+	return c.randr
+}
+
+// monitors returns the active RandR monitors in root window coordinates,
+// the primary monitor first
+func (c *x11Conn) monitors() ([]Rect, error) {
+	opcode := c.randrOpcode()
+	if opcode == 0 {
+		return nil, errors.New("RandR 1.5 not available")
+	}
+	var body bytes.Buffer
+	writeUint32(&body, c.root)
+	body.Write([]byte{1, 0, 0, 0}) // Active monitors only
+	reply, err := c.request(opcode, randrGetMonitors, body.Bytes(), true)
+	if err != nil {
+		return nil, err
+	}
+
+	// Every monitor: name, primary, automatic, output count, x, y, width,
+	// height, size in mm and its outputs
+	count := int(binary.LittleEndian.Uint32(reply[12:]))
+	var primary, others []Rect
+	for i, offset := 0, 32; i < count && offset+24 <= len(reply); i++ {
+		outputs := int(binary.LittleEndian.Uint16(reply[offset+6:]))
+		monitor := Rect{
+			X:      int(int16(binary.LittleEndian.Uint16(reply[offset+8:]))),
+			Y:      int(int16(binary.LittleEndian.Uint16(reply[offset+10:]))),
+			Width:  int(binary.LittleEndian.Uint16(reply[offset+12:])),
+			Height: int(binary.LittleEndian.Uint16(reply[offset+14:])),
+		}
+		if reply[offset+4] != 0 {
+			primary = append(primary, monitor)
+		} else {
+			others = append(others, monitor)
+		}
+		offset += 24 + 4*outputs
+	}
+	return append(primary, others...), nil
+}
+
+// pad4 pads a request field to a multiple of 4 bytes
+func pad4(data []byte) []byte {
+	if rest := len(data) % 4; rest != 0 {
+		return append(data[:len(data):len(data)], make([]byte, 4-rest)...)
+	}
+	return data
+}
+
+func writeUint16(buf *bytes.Buffer, value uint16) {
+	binary.Write(buf, binary.LittleEndian, value)
+}
+
+func writeUint32(buf *bytes.Buffer, value uint32) {
+	binary.Write(buf, binary.LittleEndian, value)
+}
@@ -0,0 +1,173 @@
+//go:build linux
+// +build linux
+
+package modWindowMemory
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+// X11 opcodes only needed to set up the tests
+const (
+	x11OpCreateWindow   = 1
+	x11OpChangeProperty = 18
+	x11OpGetInputFocus  = 43
+)
+
+// startXvfb starts an Xvfb server with one 1280x1024 screen and connects to
+// it. Skips the test if Xvfb isn't installed.
+func startXvfb(t *testing.T) *x11Conn {
+	t.Helper()
+	xvfb, err := exec.LookPath("Xvfb")
+	if err != nil {
+		t.Skip("Xvfb not in PATH")
+	}
+
+	// -displayfd picks a free display and writes its number to fd 3
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmd := exec.Command(xvfb, "-displayfd", "3", "-screen", "0", "1280x1024x24", "-nolisten", "tcp")
+	cmd.ExtraFiles = []*os.File{w}
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+	t.Cleanup(func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	})
+
+	number, err := bufio.NewReader(r).ReadString('\n')
+	r.Close()
+	if err != nil {
+		t.Fatal("Xvfb did not report its display:", err)
+	}
+	c, err := dialX11(":" + strings.TrimSpace(number))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+// createWindow creates and maps a top-level window with _NET_WM_PID set
+func createWindow(t *testing.T, c *x11Conn, pid int, r Rect) uint32 {
+	t.Helper()
+	window := c.ids | 1
+
+	var create bytes.Buffer
+	writeUint32(&create, window)
+	writeUint32(&create, c.root)
+	writeUint16(&create, uint16(int16(r.X)))
+	writeUint16(&create, uint16(int16(r.Y)))
+	writeUint16(&create, uint16(r.Width))
+	writeUint16(&create, uint16(r.Height))
+	writeUint16(&create, 0) // Border width
+	writeUint16(&create, 1) // InputOutput
+	writeUint32(&create, 0) // Visual: CopyFromParent
+	writeUint32(&create, 0) // No attributes
+	if _, err := c.request(x11OpCreateWindow, 0, create.Bytes(), false); err != nil {
+		t.Fatal(err)
+	}
+
+	atom, err := c.atom("_NET_WM_PID")
+	if err != nil {
+		t.Fatal(err)
+	}
+	const cardinal = 6
+	var property bytes.Buffer
+	writeUint32(&property, window)
+	writeUint32(&property, atom)
+	writeUint32(&property, cardinal)
+	property.Write([]byte{32, 0, 0, 0}) // Format
+	writeUint32(&property, 1)
+	writeUint32(&property, uint32(pid))
+	if _, err := c.request(x11OpChangeProperty, 0, property.Bytes(), false); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.mapWindow(window); err != nil {
+		t.Fatal(err)
+	}
+	return window
+}
+
+// inputFocus returns the window that has the keyboard focus
+func inputFocus(t *testing.T, c *x11Conn) uint32 {
+	t.Helper()
+	reply, err := c.request(x11OpGetInputFocus, 0, nil, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return binary.LittleEndian.Uint32(reply[8:])
+}
+
+func TestX11Window(t *testing.T) {
+	c := startXvfb(t)
+	pid := os.Getpid()
+	window := createWindow(t, c, pid, Rect{X: 100, Y: 50, Width: 640, Height: 480})
+
+	found, err := c.processWindow(pid)
+	if err != nil {
+		t.Fatal("window not found by _NET_WM_PID:", err)
+	}
+	if found != window {
+		t.Fatalf("processWindow() = %#x, want %#x", found, window)
+	}
+
+	geometry := func() Rect {
+		x, y, width, height, err := c.geometry(window)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return Rect{X: x, Y: y, Width: width, Height: height}
+	}
+	if got, want := geometry(), (Rect{X: 100, Y: 50, Width: 640, Height: 480}); got != want {
+		t.Errorf("geometry() = %v, want %v", got, want)
+	}
+
+	// Without a window manager the window is configured directly
+	mask := uint16(x11ConfigX | x11ConfigY | x11ConfigWidth | x11ConfigHeight)
+	if err := c.moveResize(window, mask, 300, 200, 800, 600); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := geometry(), (Rect{X: 300, Y: 200, Width: 800, Height: 600}); got != want {
+		t.Errorf("geometry() after moveResize = %v, want %v", got, want)
+	}
+	if err := c.moveResize(window, x11ConfigX, 10, 0, 0, 0); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := geometry(), (Rect{X: 10, Y: 200, Width: 800, Height: 600}); got != want {
+		t.Errorf("geometry() after moving only X = %v, want %v", got, want)
+	}
+
+	if err := c.activate(window); err != nil {
+		t.Fatal(err)
+	}
+	if focus := inputFocus(t, c); focus != window {
+		t.Errorf("input focus after activate() = %#x, want %#x", focus, window)
+	}
+}
+
+func TestX11Monitors(t *testing.T) {
+	c := startXvfb(t)
+	if c.randrOpcode() == 0 {
+		t.Skip("Xvfb without RandR 1.5")
+	}
+	monitors, err := c.monitors()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Rect{Width: 1280, Height: 1024}
+	if len(monitors) != 1 || monitors[0] != want {
+		t.Errorf("monitors() = %v, want [%v]", monitors, want)
+	}
+}
@@ -0,0 +1,265 @@
+//go:build linux
+// +build linux
+
+package modWindowMemory
+
+import (
+	"errors"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// xdotool fallback
+//
+// Used only when the native X11 client can't reach the X server, e.g. with an
+// unusual $DISPLAY setup. If xdotool and xprop aren't installed either, there
+// is no backend and only the Wails runtime is used (see backend_linux.go).
+
+// xdotoolBackend implements windowBackend with xdotool and xprop
+type xdotoolBackend struct{}
+
+// window returns the visible window of a process
+func (xdotoolBackend) window(pid int) (uint32, error) {
+	windows, err := xdotoolSearch("--onlyvisible", "--pid", strconv.Itoa(pid))
+	if err != nil {
+		return 0, err
+	}
+	return windows[0], nil
+}
+
+func (xdotoolBackend) name() string {
+	return "xdotool"
+}
+
+func (xdotoolBackend) physicalPixels() bool {
+	return true
+}
+
+func (b xdotoolBackend) geometry(pid int) (Rect, error) {
+	window, err := b.window(pid)
+	if err != nil {
+		return Rect{}, err
+	}
+	x, y, width, height, ok := xdotoolGeometry(window, false)
+	if !ok {
+		return Rect{}, errors.New("xdotool getwindowgeometry failed")
+	}
+	return Rect{X: x, Y: y, Width: width, Height: height}, nil
+}
+
+func (b xdotoolBackend) moveResize(pid int, mask uint16, r Rect) error {
+	window, err := b.window(pid)
+	if err != nil {
+		return err
+	}
+	return xdotoolMoveResize(window, mask, r.X, r.Y, r.Width, r.Height)
+}
+
+func (b xdotoolBackend) state(pid int) (maximised, fullscreen, hidden bool, err error) {
+	window, err := b.window(pid)
+	if err != nil {
+		return false, false, false, err
+	}
+	maximised, fullscreen, hidden, ok := xpropState(window, false)
+	if !ok {
+		return false, false, false, errors.New("xprop failed")
+	}
+	return maximised, fullscreen, hidden, nil
+}
+
+func (b xdotoolBackend) setState(pid int, maximised, fullscreen bool) error {
+	window, err := b.window(pid)
+	if err != nil {
+		return err
+	}
+	if fullscreen {
+		return xdotoolAddState(window, "FULLSCREEN")
+	}
+	if maximised {
+		if err := xdotoolAddState(window, "MAXIMIZED_VERT"); err != nil {
+			return err
+		}
+		return xdotoolAddState(window, "MAXIMIZED_HORZ")
+	}
+	return nil
+}
+
+func (b xdotoolBackend) activate(pid int) error {
+	window, err := b.window(pid)
+	if err != nil {
+		return err
+	}
+	return xdotoolActivate(window)
+}
+
+func (b xdotoolBackend) desktop(pid int) (desktop, count int, err error) {
+	window, err := b.window(pid)
+	if err != nil {
+		return 0, 0, err
+	}
+	output, err := exec.Command("xdotool", "get_desktop_for_window", windowArg(window)).Output()
+	if err != nil {
+		return 0, 0, errNoDesktop // No _NET_WM_DESKTOP, or a sticky window
+	}
+	if desktop, err = strconv.Atoi(strings.TrimSpace(string(output))); err != nil {
+		return 0, 0, errNoDesktop
+	}
+	output, err = exec.Command("xdotool", "get_num_desktops").Output()
+	if err != nil {
+		return 0, 0, errNoDesktop
+	}
+	if count, err = strconv.Atoi(strings.TrimSpace(string(output))); err != nil {
+		return 0, 0, errNoDesktop
+	}
+	return desktop, count, nil
+}
+
+func (b xdotoolBackend) setDesktop(pid int, desktop int) error {
+	window, err := b.window(pid)
+	if err != nil {
+		return err
+	}
+	return exec.Command("xdotool", "set_desktop_for_window", windowArg(window), strconv.Itoa(desktop)).Run()
+}
+
+// screens reads the outputs from xrandr, like the rest of this backend
+// through external tools
+func (xdotoolBackend) screens() []Rect {
+	output, err := exec.Command("xrandr", "--query").Output()
+	if err != nil {
+		return nil
+	}
+	return parseXrandrScreens(string(output))
+}
+
+// xdotoolSearch returns the windows found by "xdotool search <args>"
+func xdotoolSearch(args ...string) ([]uint32, error) {
+	output, err := exec.Command("xdotool", append([]string{"search"}, args...)...).Output()
+	if err != nil {
+		return nil, err // xdotool not installed or no window found
+	}
+	var windows []uint32
+	for _, field := range strings.Fields(string(output)) {
+		if id, err := strconv.ParseUint(field, 10, 32); err == nil {
+			windows = append(windows, uint32(id))
+		}
+	}
+	if len(windows) == 0 {
+		return nil, errWindowNotFound
+	}
+	return windows, nil
+}
+
+// xdotoolGeometry reads the geometry of a window with "xdotool getwindowgeometry"
+func xdotoolGeometry(window uint32, dbg bool) (x, y, width, height int, ok bool) {
+	output, err := exec.Command("xdotool", "getwindowgeometry", "--shell", windowArg(window)).Output()
+	if err != nil {
+		if dbg {
+			println("[WindowPos][DEBUG] xdotool command failed:", err.Error())
+		}
+		return 0, 0, 0, 0, false
+	}
+
+	outputStr := string(output)
+	if dbg {
+		println("[WindowPos][DEBUG] xdotool raw output:", outputStr)
+	}
+
+	// Parse xdotool output format:
+	// WINDOW=123456
+	// X=100
+	// Y=200
+	// WIDTH=800
+	// HEIGHT=600
+	foundWidth, foundHeight := false, false
+	for _, line := range strings.Split(outputStr, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		parts := strings.Split(line, "=")
+		if len(parts) != 2 {
+			if dbg {
+				println("[WindowPos][DEBUG] Skipping malformed line:", line)
+			}
+			continue
+		}
+
+		key := strings.TrimSpace(parts[0])
+		valueStr := strings.TrimSpace(parts[1])
+		val, err := strconv.Atoi(valueStr)
+		if err != nil {
+			if dbg {
+				println("[WindowPos][DEBUG] Failed to parse value for", key, ":", valueStr)
+			}
+			continue
+		}
+
+		switch key {
+		case "X":
+			x = val
+		case "Y":
+			y = val
+		case "WIDTH":
+			width = val
+			foundWidth = true
+		case "HEIGHT":
+			height = val
+			foundHeight = true
+		}
+	}
+	return x, y, width, height, foundWidth && foundHeight
+}
+
+// xdotoolMoveResize moves and sizes a window with "xdotool windowmove/windowsize"
+func xdotoolMoveResize(window uint32, mask uint16, x, y, width, height int) error {
+	if mask&(x11ConfigX|x11ConfigY) != 0 {
+		err := exec.Command("xdotool", "windowmove", windowArg(window), strconv.Itoa(x), strconv.Itoa(y)).Run()
+		if err != nil {
+			return err
+		}
+	}
+	if mask&(x11ConfigWidth|x11ConfigHeight) != 0 {
+		return exec.Command("xdotool", "windowsize", windowArg(window), strconv.Itoa(width), strconv.Itoa(height)).Run()
+	}
+	return nil
+}
+
+// xpropState reads the EWMH state of a window with xprop, e.g.
+// "_NET_WM_STATE(ATOM) = _NET_WM_STATE_MAXIMIZED_VERT, _NET_WM_STATE_MAXIMIZED_HORZ"
+func xpropState(window uint32, dbg bool) (maximised, fullscreen, hidden, ok bool) {
+	output, err := exec.Command("xprop", "-id", windowArg(window), "_NET_WM_STATE").Output()
+	if err != nil {
+		if dbg {
+			println("[WindowPos][DEBUG] xprop failed:", err.Error())
+		}
+		return false, false, false, false
+	}
+	state := string(output)
+	if dbg {
+		println("[WindowPos][DEBUG] xprop state:", strings.TrimSpace(state))
+	}
+
+	maximised = strings.Contains(state, "_NET_WM_STATE_MAXIMIZED_VERT") && strings.Contains(state, "_NET_WM_STATE_MAXIMIZED_HORZ")
+	fullscreen = strings.Contains(state, "_NET_WM_STATE_FULLSCREEN")
+	hidden = strings.Contains(state, "_NET_WM_STATE_HIDDEN")
+	return maximised, fullscreen, hidden, true
+}
+
+// xdotoolAddState adds an EWMH state like "FULLSCREEN" with "xdotool windowstate"
+// (xdotool 3.2021 or newer)
+func xdotoolAddState(window uint32, property string) error {
+	return exec.Command("xdotool", "windowstate", "--add", property, windowArg(window)).Run()
+}
+
+// xdotoolActivate activates a window with "xdotool windowactivate"
+func xdotoolActivate(window uint32) error {
+	return exec.Command("xdotool", "windowactivate", windowArg(window)).Run()
+}
+
+// windowArg formats a window ID for the command line
+func windowArg(window uint32) string {
+	return strconv.FormatUint(uint64(window), 10)
+}
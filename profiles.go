@@ -0,0 +1,104 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"sync"
+)
+
+// Named profiles
+//
+// A profile is a separate set of WebView data (cookies, logins, cache), so one
+// service can be used with several accounts side by side, e.g.
+// "SimpleAI chatgpt --profile work". The default profile ("") keeps the
+// original webview directory, so existing logins survive.
+//
+//	<cache>/SimpleAI/webview                   default profile
+//	<cache>/SimpleAI/profiles/<name>/webview   named profiles
+
+// defaultProfileName is how the default profile is shown in the launcher and CLI
+const defaultProfileName = "default"
+
+// profileNamePattern restricts profile names to safe directory and socket names
+var profileNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,31}$`)
+
+// normalizeProfile validates a profile name; "default" maps to ""
+func normalizeProfile(name string) (string, error) {
+	if name == "" || name == defaultProfileName {
+		return "", nil
+	}
+	if !profileNamePattern.MatchString(name) {
+		return "", fmt.Errorf("invalid profile name %q: use up to 32 characters a-z, 0-9, '-' and '_'", name)
+	}
+	return name, nil
+}
+
+// cacheBaseDir returns <cache>/SimpleAI, falling back to the config dir.
+// Resolved once, before applyWebviewDataPath redirects $XDG_CACHE_HOME on Linux.
+var cacheBaseDir = sync.OnceValue(func() string {
+	userDataDir, err := os.UserCacheDir()
+	if err != nil {
+		// Fallback to config dir if cache dir fails
+		userDataDir, _ = os.UserConfigDir()
+	}
+	return filepath.Join(userDataDir, "SimpleAI")
+})
+
+// profilesDir returns the directory holding named profiles
+func profilesDir() string {
+	return filepath.Join(cacheBaseDir(), "profiles")
+}
+
+// webviewDataPath returns the WebView storage directory of a profile
+func webviewDataPath(profile string) string {
+	if profile == "" {
+		return filepath.Join(cacheBaseDir(), "webview")
+	}
+	return filepath.Join(profilesDir(), profile, "webview")
+}
+
+// listProfiles returns "default" followed by all named profiles
+func listProfiles() []string {
+	profiles := []string{}
+	entries, err := os.ReadDir(profilesDir())
+	if err == nil {
+		for _, entry := range entries {
+			if entry.IsDir() && profileNamePattern.MatchString(entry.Name()) {
+				profiles = append(profiles, entry.Name())
+			}
+		}
+	}
+	sort.Strings(profiles)
+	return append([]string{defaultProfileName}, profiles...)
+}
+
+// createProfile creates the storage directory of a named profile
+func createProfile(name string) error {
+	profile, err := normalizeProfile(name)
+	if err != nil {
+		return err
+	}
+	if profile == "" {
+		return nil // Default profile always exists
+	}
+	return os.MkdirAll(webviewDataPath(profile), 0700)
+}
+
+// profileKey appends the profile to an instance or page key ("chatgpt@work")
+func profileKey(base, profile string) string {
+	if profile == "" {
+		return base
+	}
+	return base + "@" + profile
+}
+
+// profileTitle appends the profile to a window title ("SimpleAI - ChatGPT [work]")
+func profileTitle(title, profile string) string {
+	if profile == "" {
+		return title
+	}
+	return title + " [" + profile + "]"
+}
@@ -0,0 +1,273 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Service describes one AI service that SimpleAI can open in its own window.
+//
+// The registry is the single source of truth for service metadata: the launcher
+// (via GetServices), window titles and window lookup all read from it, so a
+// service only has to be added in one place.
+type Service struct {
+	ID          string   `json:"id"`                // Stable identifier, used as command-line argument
+	Label       string   `json:"label"`             // Human readable name, used in window titles
+	URL         string   `json:"url"`               // Start page of the service
+	Description string   `json:"description"`       // HTML description shown in the launcher info dialog
+	Aliases     []string `json:"aliases,omitempty"` // Alternative command-line names (e.g. "gpt" for chatgpt)
+	Icon        string   `json:"icon,omitempty"`    // Icon URL shown on the launcher button
+	Custom      bool     `json:"custom,omitempty"`  // Defined by the user in services.json
+}
+
+// ServiceRegistry holds the known services in launcher order
+type ServiceRegistry struct {
+	services []Service
+	byName   map[string]int // Lowercase ID or alias -> index into services
+	problems []string       // Malformed custom service entries, shown in the launcher
+}
+
+// builtinServices are the services shipped with SimpleAI
+var builtinServices = []Service{
+	{
+		ID:    "chatgpt",
+		Label: "ChatGPT",
+		URL:   "https://chatgpt.com",
+		Description: "<b>Most popular general-purpose AI</b><br><br>" +
+			"Powered by OpenAI's GPT-4 and GPT-5 models. Excels at creative writing, code generation, problem-solving, and conversational tasks. Fast response times with multimodal capabilities (text, images, voice).<br><br>" +
+			"<b>Best for:</b> Content creation, coding assistance, learning, brainstorming, and everyday tasks.",
+		Aliases: []string{"gpt", "openai"},
+		Icon:    "https://chatgpt.com/favicon.ico",
+	},
+	{
+		ID:    "claude",
+		Label: "Claude (Sonnet)",
+		URL:   "https://claude.ai",
+		Description: "<b>Deep reasoning and analysis</b><br><br>" +
+			"Anthropic's Claude Sonnet excels at nuanced understanding, long-context analysis (200K+ tokens), and following complex instructions. Strong ethical guidelines and safety focus. Better at structured analysis than creative tasks.<br><br>" +
+			"<b>Best for:</b> Document analysis, research synthesis, technical writing, code review, and ethical reasoning.",
+		Aliases: []string{"anthropic", "sonnet"},
+		Icon:    "https://claude.ai/favicon.ico",
+	},
+	{
+		ID:    "copilot",
+		Label: "Copilot",
+		URL:   "https://copilot.microsoft.com",
+		Description: "<b>Microsoft ecosystem integration</b><br><br>" +
+			"Integrated with Microsoft 365 apps (Word, Excel, PowerPoint, Outlook). Combines GPT-4 with Bing search for grounded, up-to-date answers. Supports plugins and organizational data access with enterprise security.<br><br>" +
+			"<b>Best for:</b> Office productivity, business workflows, enterprise tasks, and real-time web research.",
+		Aliases: []string{"microsoft", "bing"},
+		Icon:    "https://copilot.microsoft.com/favicon.ico",
+	},
+	{
+		ID:    "deepseek",
+		Label: "Deepseek",
+		URL:   "https://chat.deepseek.com/",
+		Description: "<b>Advanced reasoning and coding</b><br><br>" +
+			"Chinese open-source model (DeepSeek-V3.2) with strong mathematical and coding capabilities. Features chain-of-thought reasoning and competitive performance at lower costs. Newly enhanced with agent capabilities and thinking modes.<br><br>" +
+			"<b>Best for:</b> Complex coding tasks, mathematical problem-solving, algorithmic challenges, and cost-effective AI access.",
+		Icon: "https://chat.deepseek.com/favicon.ico",
+	},
+	{
+		ID:    "gemini",
+		Label: "Gemini",
+		URL:   "https://gemini.google.com",
+		Description: "<b>Google's multimodal powerhouse</b><br><br>" +
+			"Latest Gemini 2.0 Flash and 2.5 Pro models with advanced multimodal understanding (text, images, video, audio). Deep integration with Google Workspace and Search. Excels at visual tasks, data analysis, and creative content.<br><br>" +
+			"<b>Best for:</b> Image generation, video analysis, Google Workspace tasks, research with web grounding, and visual creativity.",
+		Aliases: []string{"google", "bard"},
+		Icon:    "https://gemini.google.com/favicon.ico",
+	},
+	{
+		ID:    "grok",
+		Label: "Grok",
+		URL:   "https://grok.com",
+		Description: "<b>Real-time X/Twitter integration</b><br><br>" +
+			"X's AI with direct access to real-time X/Twitter data and trending topics. More conversational and less filtered than competitors. Developed by xAI with focus on truthfulness and current events awareness.<br><br>" +
+			"<b>Best for:</b> Social media insights, trending topics, current events, real-time news analysis, and uncensored conversations.",
+		Aliases: []string{"xai"},
+		Icon:    "https://grok.com/favicon.ico",
+	},
+	{
+		ID:    "meta",
+		Label: "Meta AI",
+		URL:   "https://www.meta.ai",
+		Description: "<b>Social-first AI assistant</b><br><br>" +
+			"Meta's LLaMA-powered AI integrated across Facebook, Instagram, and WhatsApp. Focuses on conversational AI, image generation, and social interactions. Privacy-conscious with transparent data usage policies.<br><br>" +
+			"<b>Best for:</b> Social media content, casual conversations, image creation, and Facebook/Instagram-related tasks.",
+		Aliases: []string{"metaai", "llama"},
+		Icon:    "https://www.meta.ai/favicon.ico",
+	},
+	{
+		ID:    "perplexity",
+		Label: "Perplexity",
+		URL:   "https://www.perplexity.ai",
+		Description: "<b>AI-powered research engine</b><br><br>" +
+			"Combines conversational AI with real-time web search and citations. Every answer includes source links for verification. Excels at research, fact-checking, and providing up-to-date information with transparency.<br><br>" +
+			"<b>Best for:</b> Academic research, fact-checking, current events, cited answers, and information discovery with sources.",
+		Aliases: []string{"pplx"},
+		Icon:    "https://www.perplexity.ai/favicon.ico",
+	},
+}
+
+// NewServiceRegistry creates a registry containing the built-in services
+func NewServiceRegistry() *ServiceRegistry {
+	r := &ServiceRegistry{
+		byName: make(map[string]int),
+	}
+	for _, s := range builtinServices {
+		r.add(s)
+	}
+	return r
+}
+
+// add appends a service and indexes its ID and aliases.
+// Names that are already taken are not re-indexed, so earlier services win.
+func (r *ServiceRegistry) add(s Service) {
+	idx := len(r.services)
+	r.services = append(r.services, s)
+	for _, name := range append([]string{s.ID}, s.Aliases...) {
+		name = strings.ToLower(name)
+		if _, taken := r.byName[name]; !taken {
+			r.byName[name] = idx
+		}
+	}
+}
+
+// All returns a copy of all services in launcher order
+func (r *ServiceRegistry) All() []Service {
+	services := make([]Service, len(r.services))
+	copy(services, r.services)
+	return services
+}
+
+// Lookup finds a service by ID or alias (case-insensitive)
+func (r *ServiceRegistry) Lookup(name string) (Service, bool) {
+	idx, ok := r.byName[strings.ToLower(strings.TrimSpace(name))]
+	if !ok {
+		return Service{}, false
+	}
+	return r.services[idx], true
+}
+
+// WindowTitle returns the window title used for a service ID.
+// An empty or unknown ID yields the launcher title "SimpleAI".
+func (r *ServiceRegistry) WindowTitle(serviceID string) string {
+	if s, ok := r.Lookup(serviceID); ok {
+		return "SimpleAI - " + s.Label
+	}
+	return "SimpleAI"
+}
+
+// serviceIDPattern restricts IDs to names that are safe as command-line
+// arguments and file names
+var serviceIDPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// checkServiceName checks a custom service ID or alias against
+// serviceIDPattern and the reserved command names
+func checkServiceName(kind, name string) error {
+	if !serviceIDPattern.MatchString(name) {
+		return fmt.Errorf("%s %q may only contain a-z, 0-9, '-' and '_'", kind, name)
+	}
+	if slices.Contains(reservedNames, name) {
+		return fmt.Errorf("%s %q is reserved for a command", kind, name)
+	}
+	return nil
+}
+
+// LoadCustomServices reads user-defined services from a JSON file and adds them
+// after the built-in services.
+//
+// The file contains an array of service objects, e.g.
+//
+//	[{"id": "webui", "label": "Open WebUI", "url": "https://webui.example.com"}]
+//
+// A missing file is not an error. Every malformed entry is skipped and reported
+// in the returned slice so the caller can show it to the user.
+func (r *ServiceRegistry) LoadCustomServices(path string) []error {
+	problems := r.loadCustomServices(path)
+	for _, p := range problems {
+		r.problems = append(r.problems, p.Error())
+	}
+	return problems
+}
+
+// Problems returns the errors found while loading custom services
+func (r *ServiceRegistry) Problems() []string {
+	return append([]string(nil), r.problems...)
+}
+
+func (r *ServiceRegistry) loadCustomServices(path string) []error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return []error{err}
+	}
+
+	var entries []json.RawMessage
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return []error{fmt.Errorf("%s: expected a JSON array of services: %w", filepath.Base(path), err)}
+	}
+
+	var problems []error
+	for i, raw := range entries {
+		service, err := r.parseCustomService(raw)
+		if err != nil {
+			problems = append(problems, fmt.Errorf("%s entry %d: %w", filepath.Base(path), i+1, err))
+			continue
+		}
+		r.add(service)
+	}
+	return problems
+}
+
+// parseCustomService decodes and validates one entry of services.json
+func (r *ServiceRegistry) parseCustomService(raw json.RawMessage) (Service, error) {
+	var s Service
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.DisallowUnknownFields() // Report typos like "lable" instead of ignoring them
+	if err := dec.Decode(&s); err != nil {
+		return Service{}, err
+	}
+
+	s.ID = strings.ToLower(strings.TrimSpace(s.ID))
+	s.Label = strings.TrimSpace(s.Label)
+	s.URL = strings.TrimSpace(s.URL)
+	s.Custom = true
+
+	if s.ID == "" {
+		return Service{}, fmt.Errorf("missing \"id\"")
+	}
+	if err := checkServiceName("id", s.ID); err != nil {
+		return Service{}, err
+	}
+	if _, taken := r.Lookup(s.ID); taken {
+		return Service{}, fmt.Errorf("id %q is already used by another service", s.ID)
+	}
+	if s.Label == "" {
+		return Service{}, fmt.Errorf("service %q: missing \"label\"", s.ID)
+	}
+	u, err := url.Parse(s.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return Service{}, fmt.Errorf("service %q: \"url\" must be an absolute http(s) URL, got %q", s.ID, s.URL)
+	}
+	for _, alias := range s.Aliases {
+		if err := checkServiceName("alias", alias); err != nil {
+			return Service{}, fmt.Errorf("service %q: %w", s.ID, err)
+		}
+		if existing, taken := r.Lookup(alias); taken {
+			return Service{}, fmt.Errorf("service %q: alias %q is already used by %q", s.ID, alias, existing.ID)
+		}
+	}
+
+	return s, nil
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeServices writes a services.json into a temp dir and returns its path
+func writeServices(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "services.json")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadCustomServices(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		added    []string // IDs of the custom services, in order
+		problems []string // One fragment per expected problem
+	}{
+		{
+			name:    "valid",
+			content: `[{"id": "webui", "label": "Open WebUI", "url": "https://webui.example.com", "aliases": ["owui"]}]`,
+			added:   []string{"webui"},
+		},
+		{
+			name:    "id is normalised",
+			content: `[{"id": " WebUI ", "label": "Open WebUI", "url": "http://localhost:8080"}]`,
+			added:   []string{"webui"},
+		},
+		{
+			name:    "empty array",
+			content: `[]`,
+		},
+		{
+			name:     "not an array",
+			content:  `{"id": "webui"}`,
+			problems: []string{"expected a JSON array"},
+		},
+		{
+			name: "invalid entries are skipped, valid ones kept",
+			content: `[
+				{"id": "bad id", "label": "Bad", "url": "https://bad.example.com"},
+				{"id": "nourl", "label": "No URL"},
+				{"id": "ftp", "label": "FTP", "url": "ftp://files.example.com"},
+				{"id": "nolabel", "url": "https://nolabel.example.com"},
+				{"label": "No ID", "url": "https://noid.example.com"},
+				{"id": "typo", "lable": "Typo", "url": "https://typo.example.com"},
+				{"id": "ok", "label": "OK", "url": "https://ok.example.com"}
+			]`,
+			added: []string{"ok"},
+			problems: []string{
+				`entry 1: id "bad id" may only contain`,
+				`entry 2: service "nourl": "url" must be an absolute http(s) URL`,
+				`entry 3: service "ftp": "url" must be an absolute http(s) URL`,
+				`entry 4: service "nolabel": missing "label"`,
+				`entry 5: missing "id"`,
+				`entry 6: json: unknown field "lable"`,
+			},
+		},
+		{
+			name: "duplicate ids",
+			content: `[
+				{"id": "webui", "label": "Open WebUI", "url": "https://webui.example.com"},
+				{"id": "webui", "label": "Second", "url": "https://second.example.com"}
+			]`,
+			added:    []string{"webui"},
+			problems: []string{`entry 2: id "webui" is already used`},
+		},
+		{
+			name: "built-in services can't be overridden",
+			content: `[
+				{"id": "chatgpt", "label": "My ChatGPT", "url": "https://chat.example.com"},
+				{"id": "gpt", "label": "Alias clash", "url": "https://gpt.example.com"},
+				{"id": "mine", "label": "Mine", "url": "https://mine.example.com", "aliases": ["claude"]}
+			]`,
+			problems: []string{
+				`entry 1: id "chatgpt" is already used`,
+				`entry 2: id "gpt" is already used`,
+				`entry 3: service "mine": alias "claude" is already used by "claude"`,
+			},
+		},
+		{
+			name: "reserved names",
+			content: `[
+				{"id": "open", "label": "Open", "url": "https://open.example.com"},
+				{"id": "mine", "label": "Mine", "url": "https://mine.example.com", "aliases": ["launcher"]},
+				{"id": "other", "label": "Other", "url": "https://other.example.com", "aliases": ["Bad Alias"]}
+			]`,
+			problems: []string{
+				`entry 1: id "open" is reserved for a command`,
+				`entry 2: service "mine": alias "launcher" is reserved for a command`,
+				`entry 3: service "other": alias "Bad Alias" may only contain`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewServiceRegistry()
+			problems := r.LoadCustomServices(writeServices(t, tt.content))
+
+			var added []string
+			for _, s := range r.All() {
+				if s.Custom {
+					added = append(added, s.ID)
+				}
+			}
+			if strings.Join(added, ",") != strings.Join(tt.added, ",") {
+				t.Errorf("custom services %v, want %v", added, tt.added)
+			}
+
+			if len(problems) != len(tt.problems) {
+				t.Fatalf("problems %q, want %d", problems, len(tt.problems))
+			}
+			shown := r.Problems()
+			for i, want := range tt.problems {
+				if !strings.Contains(problems[i].Error(), want) {
+					t.Errorf("problem %d = %q, want it to contain %q", i+1, problems[i], want)
+				}
+				if shown[i] != problems[i].Error() {
+					t.Errorf("launcher shows %q, want %q", shown[i], problems[i])
+				}
+			}
+		})
+	}
+}
+
+func TestLoadCustomServicesMissingFile(t *testing.T) {
+	r := NewServiceRegistry()
+	if problems := r.LoadCustomServices(filepath.Join(t.TempDir(), "services.json")); problems != nil {
+		t.Errorf("missing file reported %v", problems)
+	}
+	if len(r.All()) != len(builtinServices) {
+		t.Errorf("%d services, want the %d built-in ones", len(r.All()), len(builtinServices))
+	}
+}
+
+func TestLookup(t *testing.T) {
+	r := NewServiceRegistry()
+	r.LoadCustomServices(writeServices(t, `[{"id": "webui", "label": "Open WebUI", "url": "https://webui.example.com", "aliases": ["owui"]}]`))
+
+	tests := []struct {
+		name, want string
+	}{
+		{"chatgpt", "chatgpt"},
+		{"GPT", "chatgpt"},
+		{" claude ", "claude"},
+		{"owui", "webui"},
+		{"unknown", ""},
+	}
+	for _, tt := range tests {
+		s, ok := r.Lookup(tt.name)
+		if ok != (tt.want != "") || s.ID != tt.want {
+			t.Errorf("Lookup(%q) = %q, %v; want %q", tt.name, s.ID, ok, tt.want)
+		}
+	}
+	if got := r.WindowTitle("owui"); got != "SimpleAI - Open WebUI" {
+		t.Errorf("WindowTitle(owui) = %q", got)
+	}
+	if got := r.WindowTitle(""); got != "SimpleAI" {
+		t.Errorf("WindowTitle(\"\") = %q", got)
+	}
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Session restore
+//
+// Every service window records itself as session/<key>.json in the config
+// directory while it is open. When it quits, the entry is stamped with the
+// close time instead of being removed, since at logout every window closes
+// while the others are still open. Whenever a window quits, entries closed more
+// than sessionEndGrace earlier are deleted: those windows were closed by hand
+// while others stayed open. What remains are the windows that ended together
+// (last window closed, logout, reboot, "close all" in the launcher), which the
+// launcher or "SimpleAI --restore-session" reopens. Geometry comes from
+// windows.json as usual.
+//
+// One file per window keeps concurrent instances from overwriting each other
+// without any locking.
+
+// sessionEndGrace is how far apart windows may close and still count as
+// ending together, e.g. one after the other during logout
+const sessionEndGrace = 30 * time.Second
+
+// SessionWindow is one window of the previous session
+type SessionWindow struct {
+	Service string    `json:"service"`
+	Profile string    `json:"profile"`
+	Opened  time.Time `json:"opened"`
+	Closed  time.Time `json:"closed"` // Zero while open, after a crash or for "close all"
+}
+
+// sessionDir returns the directory holding the session entries
+func sessionDir(configDir string) string {
+	return filepath.Join(configDir, "SimpleAI", "session")
+}
+
+// sessionEntryPath returns the entry file of an instance key
+func sessionEntryPath(configDir, key string) string {
+	return filepath.Join(sessionDir(configDir), key+".json")
+}
+
+// recordSessionWindow marks a window as open
+func recordSessionWindow(configDir, key string, window SessionWindow) error {
+	data, err := json.Marshal(window)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(sessionEntryPath(configDir, key), data, 0644)
+}
+
+// endSessionWindow updates the session when a window quits: entries of windows
+// closed by hand while this one stayed open are removed, and this window's
+// entry gets its close time unless it should stay in the session regardless
+func endSessionWindow(configDir, key string, keep bool, now time.Time) {
+	entries, _ := os.ReadDir(sessionDir(configDir))
+	for _, entry := range entries {
+		path := filepath.Join(sessionDir(configDir), entry.Name())
+		if window, ok := readSessionEntry(path); ok && !window.Closed.IsZero() && now.Sub(window.Closed) > sessionEndGrace {
+			os.Remove(path)
+		}
+	}
+
+	if keep {
+		return
+	}
+	path := sessionEntryPath(configDir, key)
+	window, ok := readSessionEntry(path)
+	if !ok {
+		return
+	}
+	window.Closed = now
+	data, err := json.Marshal(window)
+	if err == nil {
+		err = writeFileAtomic(path, data, 0644)
+	}
+	if err != nil {
+		println("[Session] Could not record close:", err.Error())
+	}
+}
+
+// readSessionEntry reads one session entry
+func readSessionEntry(path string) (SessionWindow, bool) {
+	var window SessionWindow
+	if !strings.HasSuffix(path, ".json") {
+		return window, false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || json.Unmarshal(data, &window) != nil {
+		return window, false
+	}
+	return window, true
+}
+
+// previousSession returns the recorded windows that are not running right now,
+// sorted by the time they were opened
+func previousSession(configDir string, services *ServiceRegistry) []SessionWindow {
+	entries, err := os.ReadDir(sessionDir(configDir))
+	if err != nil {
+		return nil
+	}
+
+	windows := []SessionWindow{}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		key := strings.TrimSuffix(entry.Name(), ".json")
+		path := filepath.Join(sessionDir(configDir), entry.Name())
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		var window SessionWindow
+		if json.Unmarshal(data, &window) != nil {
+			os.Remove(path)
+			continue
+		}
+		if _, ok := services.Lookup(window.Service); !ok {
+			continue // Custom service that was removed from services.json
+		}
+		if _, err := sendControl(key, controlRequest{Command: controlPing}); err == nil {
+			continue // Still open
+		}
+		windows = append(windows, window)
+	}
+
+	sort.Slice(windows, func(i, j int) bool {
+		return windows[i].Opened.Before(windows[j].Opened)
+	})
+	return windows
+}
+
+// restoreSession reopens all windows of the previous session
+func restoreSession(configDir string, services *ServiceRegistry) (int, error) {
+	restored := 0
+	for _, window := range previousSession(configDir, services) {
+		args := []string{cmdOpen, window.Service}
+		if window.Profile != "" {
+			args = append(args, "--profile", window.Profile)
+		}
+		if err := spawnInstance(args...); err != nil {
+			return restored, err
+		}
+		restored++
+	}
+	return restored, nil
+}
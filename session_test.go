@@ -0,0 +1,77 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSessionEnd(t *testing.T) {
+	t.Setenv("XDG_RUNTIME_DIR", t.TempDir()) // No instance answers pings
+	services := NewServiceRegistry()
+	start := time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+
+	type closing struct {
+		key  string
+		keep bool // Closed by "close all"
+		at   time.Duration
+	}
+	tests := []struct {
+		name    string
+		closing []closing
+		want    []string // Services of the previous session, by open time
+	}{
+		{
+			name:    "closed one by one, the last window stays",
+			closing: []closing{{key: "chatgpt", at: time.Hour}, {key: "claude", at: 2 * time.Hour}, {key: "gemini", at: 3 * time.Hour}},
+			want:    []string{"gemini"},
+		},
+		{
+			name:    "logout closes all windows together",
+			closing: []closing{{key: "gemini", at: time.Hour}, {key: "chatgpt", at: time.Hour + time.Second}, {key: "claude", at: time.Hour + 5*time.Second}},
+			want:    []string{"chatgpt", "claude", "gemini"},
+		},
+		{
+			name:    "one closed by hand, then logout",
+			closing: []closing{{key: "claude", at: time.Hour}, {key: "chatgpt", at: 2 * time.Hour}, {key: "gemini", at: 2 * time.Hour}},
+			want:    []string{"chatgpt", "gemini"},
+		},
+		{
+			name:    "crash leaves the other windows",
+			closing: []closing{{key: "claude", at: time.Hour}},
+			want:    []string{"chatgpt", "claude", "gemini"},
+		},
+		{
+			name:    "close all, then others closed by hand",
+			closing: []closing{{key: "chatgpt", keep: true, at: time.Hour}, {key: "claude", at: 2 * time.Hour}, {key: "gemini", at: 3 * time.Hour}},
+			want:    []string{"chatgpt", "gemini"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configDir := t.TempDir()
+			for i, service := range []string{"chatgpt", "claude", "gemini"} {
+				window := SessionWindow{Service: service, Opened: start.Add(time.Duration(i) * time.Minute)}
+				if err := recordSessionWindow(configDir, service, window); err != nil {
+					t.Fatal(err)
+				}
+			}
+			for _, c := range tt.closing {
+				endSessionWindow(configDir, c.key, c.keep, start.Add(c.at))
+			}
+
+			var got []string
+			for _, window := range previousSession(configDir, services) {
+				got = append(got, window.Service)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("previous session %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("previous session %v, want %v", got, tt.want)
+					break
+				}
+			}
+		})
+	}
+}
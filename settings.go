@@ -0,0 +1,79 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"SimpleAI/modWindowMemory"
+)
+
+// Settings holds user preferences edited from the launcher.
+// Stored as settings.json in the config directory; missing fields keep their defaults.
+type Settings struct {
+	// RememberLastURL turns last-page memory on or off per service ID.
+	// Services without an entry remember their last page.
+	RememberLastURL map[string]bool `json:"rememberLastUrl,omitempty"`
+
+	// Placement is how windows without a saved position are placed:
+	// "cascade" (default), "center" or "tile" (see modWindowMemory/placement.go)
+	Placement string `json:"placement,omitempty"`
+}
+
+// loadSettings reads settings.json. A missing or unreadable file yields defaults.
+func loadSettings(path string) *Settings {
+	settings := &Settings{}
+	data, err := os.ReadFile(path)
+	if err == nil {
+		if err := json.Unmarshal(data, settings); err != nil {
+			println("[Settings] Ignoring invalid settings file:", err.Error())
+			settings = &Settings{}
+		}
+	}
+	if settings.RememberLastURL == nil {
+		settings.RememberLastURL = make(map[string]bool)
+	}
+	return settings
+}
+
+// save writes settings.json
+func (s *Settings) save(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, data, 0644)
+}
+
+// remembersLastURL reports whether the last page of a service is restored
+func (s *Settings) remembersLastURL(serviceID string) bool {
+	enabled, set := s.RememberLastURL[serviceID]
+	return !set || enabled
+}
+
+// placement returns the placement strategy for new windows. An unknown name
+// is logged and replaced by the default.
+func (s *Settings) placement() modWindowMemory.PlacementStrategy {
+	if s.Placement == "" {
+		return modWindowMemory.PlaceCascade
+	}
+	strategy, err := modWindowMemory.ParsePlacement(s.Placement)
+	if err != nil {
+		println("[Settings] Ignoring placement:", err.Error())
+		return modWindowMemory.PlaceCascade
+	}
+	return strategy
+}
+
+// writeFileAtomic writes data to a temporary file and renames it over path,
+// so readers in other instances never see a half-written file
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, perm); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}